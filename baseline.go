@@ -0,0 +1,132 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// baselineKey identifies a finding independently of its line number, so
+// that edits elsewhere in the file do not break the match: the line is
+// represented by a hash of its trimmed contents.
+type baselineKey struct {
+	File   string `json:"file"`
+	RuleID string `json:"rule"`
+	Path   string `json:"path"`
+	Hash   string `json:"hash"`
+}
+
+// baselineEntry is a key in the baseline file with the number of findings
+// it stands for.
+type baselineEntry struct {
+	baselineKey
+	Count int `json:"count"`
+}
+
+// baselineFile is the format written by --write-baseline.
+type baselineFile struct {
+	Version  int             `json:"version"`
+	Findings []baselineEntry `json:"findings"`
+}
+
+func findingKey(f validator.Finding, lines []string) baselineKey {
+	text := ""
+	if f.Line > 0 && f.Line <= len(lines) {
+		text = strings.TrimSpace(lines[f.Line-1])
+	}
+	sum := sha256.Sum256([]byte(text))
+	return baselineKey{File: f.File, RuleID: f.RuleID, Path: f.Path, Hash: hex.EncodeToString(sum[:8])}
+}
+
+// baseline holds the findings of a baseline file that have not been matched
+// yet, and those that have.
+type baseline struct {
+	remaining map[baselineKey]int
+	matched   map[baselineKey]int
+}
+
+func loadBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file baselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	b := &baseline{remaining: map[baselineKey]int{}, matched: map[baselineKey]int{}}
+	for _, e := range file.Findings {
+		b.remaining[e.baselineKey] += e.Count
+	}
+	return b, nil
+}
+
+// match marks the findings of one file that the baseline covers as
+// suppressed. lines holds the contents of the file.
+func (b *baseline) match(findings []validator.Finding, lines []string) {
+	for i, f := range findings {
+		if f.Suppressed || inputRules[f.RuleID] {
+			continue
+		}
+		if k := findingKey(f, lines); b.remaining[k] > 0 {
+			b.remaining[k]--
+			b.matched[k]++
+			findings[i].Suppressed = true
+		}
+	}
+}
+
+// stale returns the entries of the files checked that no longer match a
+// finding.
+func (b *baseline) stale(files []string) []baselineEntry {
+	var entries []baselineEntry
+	for k, n := range b.remaining {
+		if n > 0 && slices.Contains(files, k.File) {
+			entries = append(entries, baselineEntry{k, n})
+		}
+	}
+	sortBaseline(entries)
+	return entries
+}
+
+// pruned returns the entries to keep when stale ones are removed: the
+// matched ones and those of files that were not checked.
+func (b *baseline) pruned(files []string) map[baselineKey]int {
+	counts := maps.Clone(b.matched)
+	for k, n := range b.remaining {
+		if !slices.Contains(files, k.File) {
+			counts[k] += n
+		}
+	}
+	return counts
+}
+
+// writeBaseline writes the findings counted in counts to path.
+func writeBaseline(path string, counts map[baselineKey]int) error {
+	file := baselineFile{Version: 1, Findings: []baselineEntry{}}
+	for k, n := range counts {
+		if n > 0 {
+			file.Findings = append(file.Findings, baselineEntry{k, n})
+		}
+	}
+	sortBaseline(file.Findings)
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func sortBaseline(entries []baselineEntry) {
+	slices.SortFunc(entries, func(a, b baselineEntry) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.RuleID, b.RuleID), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Hash, b.Hash))
+	})
+}
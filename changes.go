@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// changedLines holds the lines added or modified in each file, for
+// --diff-base and --changed-lines-from. Files are keyed by their cleaned
+// path relative to root.
+type changedLines struct {
+	lines map[string]map[int]bool
+	// whole holds the files that are new as a whole, such as untracked
+	// files, every line of which counts as changed.
+	whole map[string]bool
+	// root is the directory the paths of the diff are relative to: the top
+	// of the work tree for git, and the working directory if it is empty.
+	root string
+}
+
+// parseUnifiedDiff reads the new-side line numbers of the added lines of a
+// unified diff, as printed by git diff or diff -u, with any context.
+func parseUnifiedDiff(r io.Reader) (*changedLines, error) {
+	changes := &changedLines{lines: map[string]map[int]bool{}, whole: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var file string
+	line := 0
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name, _, _ := strings.Cut(strings.TrimPrefix(text, "+++ "), "\t")
+			file = ""
+			if name != "/dev/null" {
+				file = diffPath(strings.TrimPrefix(name, "b/"))
+			}
+		case strings.HasPrefix(text, "--- "):
+		case strings.HasPrefix(text, "@@ "):
+			// @@ -a,b +c,d @@
+			fields := strings.Fields(text)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				return nil, fmt.Errorf("malformed hunk header %q", text)
+			}
+			start, _, _ := strings.Cut(fields[2][1:], ",")
+			n, err := strconv.Atoi(start)
+			if err != nil {
+				return nil, fmt.Errorf("malformed hunk header %q", text)
+			}
+			line = n
+		case file == "" || line == 0:
+		case strings.HasPrefix(text, "+"):
+			if changes.lines[file] == nil {
+				changes.lines[file] = map[int]bool{}
+			}
+			changes.lines[file][line] = true
+			line++
+		case strings.HasPrefix(text, " "), text == "":
+			line++
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// gitChangedLines returns the lines changed in the working tree relative
+// to ref, counting untracked files as changed throughout.
+// Paths are taken relative to the top of the work tree, so files outside
+// the working directory are covered too.
+func gitChangedLines(ref string) (*changedLines, error) {
+	top, err := runGit("", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.FromSlash(strings.TrimSpace(string(top)))
+	out, err := runGit(root, "diff", "-U0", "--no-color", "--no-ext-diff", "--no-relative", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseUnifiedDiff(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	changes.root = root
+	untracked, err := runGit(root, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(strings.TrimSpace(string(untracked)), "\n") {
+		if name != "" {
+			changes.whole[diffPath(name)] = true
+		}
+	}
+	return changes, nil
+}
+
+// runGit runs git in dir, or in the working directory if dir is empty.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// diffPath turns a file name from a diff into the key of changedLines.
+func diffPath(name string) string {
+	if filepath.IsAbs(name) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, name); err == nil {
+				name = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+// key returns the key of changedLines for the file name of a finding,
+// which is relative to the working directory.
+func (c *changedLines) key(name string) string {
+	if c.root == "" {
+		return diffPath(name)
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return diffPath(name)
+	}
+	// The top of the work tree has its symbolic links resolved
+	root := c.root
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return diffPath(name)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// touches reports whether f is on a changed line or, if it is about an
+// absent field and so reported at a parent that may not have changed,
+// whether any line of its object changed. Findings without a line, such
+// as read errors, always count.
+func (c *changedLines) touches(f validator.Finding) bool {
+	file := c.key(f.File)
+	if f.Line == 0 || c.whole[file] {
+		return true
+	}
+	lines := c.lines[file]
+	if lines[f.Line] {
+		return true
+	}
+	if first, last := f.ObjectLines(); f.AboutAbsentField() && first > 0 {
+		for l := first; l <= last; l++ {
+			if lines[l] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dropUnchanged drops the findings outside the changed lines and returns
+// how many it dropped.
+func (c *changedLines) dropUnchanged(findings []validator.Finding) ([]validator.Finding, int) {
+	kept := findings[:0]
+	for _, f := range findings {
+		if c.touches(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept, len(findings) - len(kept)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI SGR codes used by the text format.
+const (
+	ansiBold   = "1"
+	ansiDim    = "2"
+	ansiRed    = "31"
+	ansiYellow = "33"
+	ansiCyan   = "36"
+)
+
+// painter wraps text in ANSI escape codes when color is enabled.
+type painter bool
+
+func (p painter) paint(code, s string) string {
+	if !p {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// colorEnabled resolves --color for output written to f. In auto mode color
+// is used on terminals unless NO_COLOR is set.
+func colorEnabled(mode string, f *os.File) (painter, error) {
+	switch mode {
+	case "always":
+		enableVirtualTerminal(f)
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false, nil
+		}
+		return painter(enableVirtualTerminal(f)), nil
+	}
+	return false, fmt.Errorf("unknown color mode %q, want always, never or auto", mode)
+}
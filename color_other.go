@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableVirtualTerminal reports whether f understands ANSI escapes, which
+// every terminal outside Windows does.
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
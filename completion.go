@@ -0,0 +1,295 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// completeCommand is the hidden subcommand the completion scripts run to
+// ask for the candidates of the word being completed. Its arguments are
+// the words of the command line after the program name, the last one being
+// the word under the cursor.
+const completeCommand = "__complete"
+
+// The candidates that stand for file names, which the completion scripts
+// have the shell fill in.
+const (
+	manifestFiles = ":files" // .yaml, .yml and .json files, and directories
+	anyFiles      = ":all"
+)
+
+// completionProbe, when set, is handed the flag set of the command being
+// completed by parseFlags, which then stops the command before it parses
+// anything or does any work.
+var completionProbe func(fs *flag.FlagSet)
+
+// parseFlags parses the arguments of a command. When it returns false the
+// command must exit with the code it returns.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if completionProbe != nil {
+		completionProbe(fs)
+		return exitClean, false
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitClean, false
+		}
+		return exitUsage, false
+	}
+	return exitClean, true
+}
+
+// argCompletions give the candidates for the positional arguments of the
+// subcommands that take any.
+var argCompletions = map[string]func() []string{
+	"completion": func() []string { return slices.Clone(shells) },
+	"diff":       func() []string { return []string{manifestFiles} },
+	"explain":    ruleIDs,
+	"fmt":        func() []string { return []string{manifestFiles} },
+	"get":        func() []string { return []string{manifestFiles} },
+}
+
+// flagCompletions give the candidates for the values of flags, by flag
+// name. A nil entry is a flag naming a file of any kind.
+var flagCompletions = map[string]func(fs *flag.FlagSet) []string{
+	"baseline":           nil,
+	"changed-lines-from": nil,
+	"color":              func(*flag.FlagSet) []string { return []string{"always", "never", "auto"} },
+	"config":             nil,
+	"disable":            func(*flag.FlagSet) []string { return ruleIDs() },
+	"enable":             func(*flag.FlagSet) []string { return ruleIDs() },
+	"format": func(fs *flag.FlagSet) []string {
+		if fs != flag.CommandLine {
+			return []string{"text", "json"}
+		}
+		return strings.Split(formatNames(), ", ")
+	},
+	"mode":           func(*flag.FlagSet) []string { return []string{"deny", "warn"} },
+	"preset":         func(*flag.FlagSet) []string { return validator.Presets() },
+	"schema-dir":     nil,
+	"tls-cert-file":  nil,
+	"tls-key-file":   nil,
+	"write-baseline": nil,
+}
+
+// listFlags are the flags that take comma-separated lists, whose last item
+// is the one completed.
+var listFlags = map[string]bool{"disable": true, "enable": true, "preset": true}
+
+func ruleIDs() []string {
+	var ids []string
+	for _, r := range validator.Rules() {
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// complete answers the completion scripts for words. A word after a
+// subcommand name is completed here; for the validating command it sets
+// completionProbe and returns false, so that main completes it once its
+// flags are defined.
+func complete(words []string) (int, bool) {
+	if len(words) == 0 {
+		return exitClean, true
+	}
+	if len(words) > 1 {
+		if run, ok := subcommands[words[0]]; ok {
+			completionProbe = func(fs *flag.FlagSet) {
+				printCompletions(os.Stdout, fs, words[1:], argCompletions[words[0]])
+			}
+			run(nil)
+			return exitClean, true
+		}
+	}
+	completionProbe = func(fs *flag.FlagSet) {
+		args := func() []string { return []string{manifestFiles} }
+		if len(words) == 1 {
+			args = func() []string {
+				names := []string{manifestFiles}
+				for name := range subcommands {
+					names = append(names, name)
+				}
+				sort.Strings(names[1:])
+				return names
+			}
+		}
+		printCompletions(os.Stdout, fs, words, args)
+	}
+	return exitClean, false
+}
+
+// printCompletions prints the candidates for the last of words, one per
+// line, given the flags of the command and the candidates for its
+// positional arguments, if it takes any.
+func printCompletions(w io.Writer, fs *flag.FlagSet, words []string, args func() []string) {
+	cur := words[len(words)-1]
+	prev := ""
+	if len(words) > 1 {
+		prev = words[len(words)-2]
+	}
+	var name, value, prefix string
+	switch {
+	// bash splits --flag=value into three words
+	case cur == "=" && isFlag(prev):
+		name = strings.TrimLeft(prev, "-")
+	case prev == "=" && len(words) > 2 && isFlag(words[len(words)-3]):
+		name, value = strings.TrimLeft(words[len(words)-3], "-"), cur
+	case isFlag(cur) && strings.Contains(cur, "="):
+		i := strings.Index(cur, "=")
+		name, value, prefix = strings.TrimLeft(cur[:i], "-"), cur[i+1:], cur[:i+1]
+	case isFlag(prev) && !strings.Contains(prev, "=") && !isBoolFlag(fs, strings.TrimLeft(prev, "-")):
+		name, value = strings.TrimLeft(prev, "-"), cur
+	case isFlag(cur):
+		fs.VisitAll(func(f *flag.Flag) {
+			if strings.HasPrefix("--"+f.Name, cur) {
+				fmt.Fprintln(w, "--"+f.Name)
+			}
+		})
+		return
+	default:
+		if args != nil {
+			for _, c := range args() {
+				if c == manifestFiles || strings.HasPrefix(c, cur) {
+					fmt.Fprintln(w, c)
+				}
+			}
+		}
+		return
+	}
+	if fs.Lookup(name) == nil {
+		return
+	}
+	values, ok := flagCompletions[name]
+	if !ok {
+		return
+	}
+	if values == nil {
+		fmt.Fprintln(w, anyFiles)
+		return
+	}
+	if listFlags[name] {
+		if i := strings.LastIndex(value, ","); i >= 0 {
+			prefix += value[:i+1]
+			value = value[i+1:]
+		}
+	}
+	for _, c := range values(fs) {
+		if strings.HasPrefix(c, value) {
+			fmt.Fprintln(w, prefix+c)
+		}
+	}
+}
+
+func isFlag(word string) bool {
+	return len(word) > 1 && word[0] == '-' && word != "--"
+}
+
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// shells are the shells completion writes scripts for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// completion runs the completion subcommand, which prints the script that
+// completes the command line in a shell.
+func completion(args []string) int {
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s completion bash|zsh|fish\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Prints a script that completes flags, rule IDs, formats and file names; load it with")
+		fmt.Fprintf(os.Stderr, "  source <(%[1]s completion bash), source <(%[1]s completion zsh) or %[1]s completion fish | source\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 || !slices.Contains(shells, fs.Arg(0)) {
+		fs.Usage()
+		return exitUsage
+	}
+	prog := filepath.Base(os.Args[0])
+	// Shell function names cannot hold every character a file name can
+	fn := "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, prog)
+	script := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}[fs.Arg(0)]
+	script = strings.NewReplacer("PROG", prog, "FUNC", fn, "COMPLETE", completeCommand,
+		"MANIFESTS", manifestFiles, "ANYFILES", anyFiles).Replace(script)
+	fmt.Print(script)
+	return exitClean
+}
+
+const bashCompletion = `# bash completion for PROG
+FUNC() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" c
+    [[ $cur == = ]] && cur=
+    local IFS=$'\n'
+    COMPREPLY=()
+    for c in $("${COMP_WORDS[0]}" COMPLETE "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null); do
+        case $c in
+        MANIFESTS)
+            COMPREPLY+=($(compgen -d -- "$cur"))
+            COMPREPLY+=($(compgen -f -X '!*.@(yaml|yml|json)' -- "$cur"))
+            ;;
+        ANYFILES) COMPREPLY+=($(compgen -f -- "$cur")) ;;
+        *) COMPREPLY+=("$c") ;;
+        esac
+    done
+}
+shopt -s extglob
+complete -o filenames -F FUNC PROG
+`
+
+const zshCompletion = `#compdef PROG
+FUNC() {
+    local c
+    local -a candidates
+    for c in "${(@f)$(${words[1]} COMPLETE "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        case $c in
+        MANIFESTS) _files -g '*.(yaml|yml|json)' ;;
+        ANYFILES) _files ;;
+        ?*) candidates+=("$c") ;;
+        esac
+    done
+    (( ${#candidates} )) && compadd -Q -- "${candidates[@]}"
+}
+compdef FUNC PROG
+`
+
+const fishCompletion = `# fish completion for PROG
+function FUNC
+    set -l words (commandline -opc) (commandline -ct)
+    for c in ($words[1] COMPLETE $words[2..-1] 2>/dev/null)
+        switch $c
+            case MANIFESTS
+                __fish_complete_directories (commandline -ct)
+                for suffix in .yaml .yml .json
+                    __fish_complete_suffix $suffix
+                end
+            case ANYFILES
+                __fish_complete_path (commandline -ct)
+            case '*'
+                echo $c
+        end
+    end
+end
+complete -c PROG -f -a '(FUNC)'
+`
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go-test-maga/validator"
+)
+
+// configFileName is the configuration file looked up next to the validated
+// files and in their parent directories.
+const configFileName = ".podlint.yaml"
+
+// configs finds and caches the configuration for each validated file.
+type configs struct {
+	// explicit is the file given with --config, which applies to every file.
+	explicit *validator.Config
+	// enable and disable hold the rule IDs given on the command line, which
+	// override the configuration files.
+	enable, disable []string
+	reportUnused    bool
+	strictFields    bool
+	// kinds and skipKinds are given with --kinds and --skip-kinds.
+	kinds, skipKinds []string
+	// presets are given with --preset.
+	presets []string
+	// targetKubeVersion is given with --target-kube-version.
+	targetKubeVersion string
+	// maxBytes, maxNodes and maxDepth are given with --max-bytes,
+	// --max-nodes and --max-depth, and are zero when not.
+	maxBytes           int64
+	maxNodes, maxDepth int
+	// schema is the OpenAPI schema given with --schema-dir or
+	// --schema-from-cluster.
+	schema *validator.Schema
+
+	mu     sync.Mutex // guards byPath, as files are checked in parallel
+	byPath map[string]*validator.Config
+}
+
+// newConfigs loads the --config file, if any, and checks the rule IDs given
+// on the command line.
+func newConfigs(explicitPath, enable, disable string) (*configs, error) {
+	c := &configs{enable: splitList(enable), disable: splitList(disable), byPath: map[string]*validator.Config{}}
+	for _, id := range append(append([]string{}, c.enable...), c.disable...) {
+		if err := validator.CheckRuleID(id); err != nil {
+			return nil, err
+		}
+	}
+
+	if explicitPath != "" {
+		cfg, err := loadConfig(explicitPath)
+		if err != nil {
+			return nil, err
+		}
+		c.explicit = cfg
+	}
+	return c, nil
+}
+
+// forFile returns the configuration for filePath: the --config file, or
+// else the nearest .podlint.yaml above the file, with the command line
+// overrides applied. Input from stdin and URLs is looked up from the
+// working directory.
+func (c *configs) forFile(filePath string) (*validator.Config, error) {
+	cfg := c.explicit
+	if cfg == nil {
+		dir := "."
+		if filePath != "-" && !isURL(filePath) {
+			dir = filepath.Dir(filePath)
+		}
+		found, err := c.discover(dir)
+		if err != nil {
+			return nil, err
+		}
+		cfg = found
+	}
+	cfg = cfg.Clone()
+	for _, id := range c.enable {
+		// Re-enable a rule turned off by a configuration file
+		if cfg.Rules[id].Severity == validator.SeverityOff {
+			cfg.SetSeverity(id, "")
+		}
+		cfg.Enable = append(cfg.Enable, id)
+	}
+	cfg.Presets = append(cfg.Presets, c.presets...)
+	for _, id := range c.disable {
+		cfg.SetSeverity(id, validator.SeverityOff)
+	}
+	if c.reportUnused {
+		cfg.ReportUnusedIgnores = true
+	}
+	if c.strictFields {
+		cfg.StrictFields = true
+	}
+	if c.kinds != nil {
+		cfg.Kinds = c.kinds
+	}
+	if c.skipKinds != nil {
+		cfg.SkipKinds = c.skipKinds
+	}
+	if c.targetKubeVersion != "" {
+		cfg.TargetKubeVersion = c.targetKubeVersion
+	}
+	if c.schema != nil {
+		cfg.Schema = c.schema
+	}
+	if c.maxBytes != 0 {
+		cfg.MaxBytes = c.maxBytes
+	}
+	if c.maxNodes != 0 {
+		cfg.MaxNodes = c.maxNodes
+	}
+	if c.maxDepth != 0 {
+		cfg.MaxDepth = c.maxDepth
+	}
+	return cfg, nil
+}
+
+// discover walks up from dir to the nearest configuration file. It returns
+// nil if there is none.
+func (c *configs) discover(dir string) (*validator.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, configFileName)
+		if cfg, ok := c.byPath[path]; ok {
+			return cfg, nil
+		}
+		if _, err := os.Stat(path); err == nil {
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return nil, err
+			}
+			c.byPath[path] = cfg
+			return cfg, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (*validator.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := validator.ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
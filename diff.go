@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"go-test-maga/validator"
+)
+
+// diff is the diff subcommand, which compares two manifests field by field
+// and exits with 1 when they differ.
+func diff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	ignorePaths := fs.String("ignore-paths", "", "comma-separated field paths to leave out, with * matching any key or item, as in metadata.annotations.*")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [flags] <old-file|-> <new-file|->\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Key order, comments and quoting are ignored, documents are matched by kind,")
+		fmt.Fprintln(os.Stderr, "namespace and name, and list items such as containers by their name.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 || *format != "text" && *format != "json" || fs.Arg(0) == "-" && fs.Arg(1) == "-" {
+		fs.Usage()
+		return exitUsage
+	}
+	var data [2][]byte
+	for i, name := range fs.Args() {
+		var err error
+		if name == "-" {
+			data[i], err = readStdin(validator.DefaultMaxBytes)
+		} else {
+			data[i], err = os.ReadFile(name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return exitInputError
+		}
+	}
+	changes, err := validator.Diff(fs.Arg(0), data[0], fs.Arg(1), data[1], splitList(*ignorePaths))
+	if errors.Is(err, validator.ErrInvalidPath) {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", syntaxName(err), err)
+		return exitInputError
+	}
+	if *format == "json" {
+		if changes == nil {
+			changes = []validator.Change{}
+		}
+		if code := writeExplainJSON(os.Stdout, changes); code != exitClean {
+			return code
+		}
+	} else {
+		object := ""
+		for _, c := range changes {
+			if c.Object != object {
+				object = c.Object
+				if c.Path != "" {
+					fmt.Printf("%s:\n", object)
+				}
+			}
+			printChange(c)
+		}
+	}
+	if len(changes) > 0 {
+		return exitFindings
+	}
+	return exitClean
+}
+
+// printChange prints a change on one line, marked + when added, - when
+// removed and ~ when changed, and indented when it is within an object
+// whose name was printed above it.
+func printChange(c validator.Change) {
+	if c.Path == "" {
+		// A whole object
+		mark := map[string]string{"added": "+", "removed": "-"}[c.Type]
+		fmt.Printf("%s %s\n", mark, c.Object)
+		return
+	}
+	indent := ""
+	if c.Object != "" {
+		indent = "  "
+	}
+	switch c.Type {
+	case "added":
+		fmt.Printf("%s+ %s: %s\n", indent, c.Path, c.New)
+	case "removed":
+		fmt.Printf("%s- %s: %s\n", indent, c.Path, c.Old)
+	default:
+		fmt.Printf("%s~ %s: %s -> %s\n", indent, c.Path, c.Old, c.New)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// ruleInfo is the JSON form of a rule printed by explain --format json.
+type ruleInfo struct {
+	ID          string                 `json:"id"`
+	Category    string                 `json:"category"`
+	Severity    validator.Severity     `json:"severity"`
+	Preset      string                 `json:"preset,omitempty"`
+	Description string                 `json:"description"`
+	Details     string                 `json:"details"`
+	Rationale   string                 `json:"rationale"`
+	Bad         string                 `json:"bad"`
+	Good        string                 `json:"good"`
+	Options     []validator.RuleOption `json:"options"`
+}
+
+func newRuleInfo(r validator.Rule) ruleInfo {
+	info := ruleInfo{ID: r.ID, Category: r.Category, Severity: r.Severity, Preset: r.Preset, Description: r.Description,
+		Details: r.Details, Rationale: r.Rationale, Bad: r.Bad, Good: r.Good, Options: r.Options}
+	if info.Options == nil {
+		info.Options = []validator.RuleOption{}
+	}
+	return info
+}
+
+// explain runs the explain subcommand, which documents one rule, or lists
+// every rule by category when none is named.
+func explain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s explain [flags] [rule-id]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 || *format != "text" && *format != "json" {
+		fs.Usage()
+		return exitUsage
+	}
+	rules := validator.Rules()
+	if fs.NArg() == 0 {
+		if *format == "json" {
+			infos := make([]ruleInfo, len(rules))
+			for i, r := range rules {
+				infos[i] = newRuleInfo(r)
+			}
+			return writeExplainJSON(os.Stdout, infos)
+		}
+		listRulesByCategory(os.Stdout, rules, terminalWidth(os.Stdout))
+		return exitClean
+	}
+	id := fs.Arg(0)
+	for _, r := range rules {
+		if r.ID != id {
+			continue
+		}
+		if *format == "json" {
+			return writeExplainJSON(os.Stdout, newRuleInfo(r))
+		}
+		explainRule(os.Stdout, r, terminalWidth(os.Stdout))
+		return exitClean
+	}
+	if s := validator.ClosestRuleID(id); s != "" {
+		fmt.Fprintf(os.Stderr, "unknown rule %q (did you mean %q?)\n", id, s)
+	} else {
+		fmt.Fprintf(os.Stderr, "unknown rule %q; run %s explain to list the rules\n", id, os.Args[0])
+	}
+	return exitUsage
+}
+
+// writeExplainJSON writes v as indented JSON, as explain and diff print it.
+func writeExplainJSON(w io.Writer, v any) int {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInputError
+	}
+	return exitClean
+}
+
+// listRulesByCategory writes every rule ID with its description under a
+// heading for each category.
+func listRulesByCategory(w io.Writer, rules []validator.Rule, width int) {
+	idWidth := 0
+	for _, r := range rules {
+		idWidth = max(idWidth, len(r.ID))
+	}
+	indent := strings.Repeat(" ", idWidth+4)
+	for i, category := range append(validator.Categories, "") {
+		var inCategory []validator.Rule
+		for _, r := range rules {
+			if r.Category == category {
+				inCategory = append(inCategory, r)
+			}
+		}
+		if len(inCategory) == 0 {
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if category == "" {
+			category = "other"
+		}
+		fmt.Fprintln(w, strings.ToUpper(category[:1])+category[1:])
+		for _, r := range inCategory {
+			fmt.Fprint(w, wrapText(r.Description, fmt.Sprintf("  %-*s  ", idWidth, r.ID), indent, width))
+		}
+	}
+}
+
+// explainRule writes the documentation of r, wrapping prose to width.
+func explainRule(w io.Writer, r validator.Rule, width int) {
+	fmt.Fprintf(w, "%s (%s", r.ID, r.Severity)
+	if r.Category != "" {
+		fmt.Fprintf(w, ", %s", r.Category)
+	}
+	if r.Preset != "" {
+		fmt.Fprintf(w, ", preset %s", r.Preset)
+	}
+	fmt.Fprintln(w, ")")
+	fmt.Fprintln(w)
+	fmt.Fprint(w, wrapText(r.Description+".", "  ", "  ", width))
+	if r.Details != "" {
+		fmt.Fprintln(w)
+		fmt.Fprint(w, wrapText(r.Details, "  ", "  ", width))
+	}
+	if r.Rationale != "" {
+		fmt.Fprintln(w, "\nWhy it matters")
+		fmt.Fprint(w, wrapText(r.Rationale, "  ", "  ", width))
+	}
+	for _, ex := range []struct{ title, yaml string }{{"Fails", r.Bad}, {"Passes", r.Good}} {
+		if ex.yaml == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", ex.title)
+		for _, line := range strings.Split(strings.TrimSuffix(ex.yaml, "\n"), "\n") {
+			fmt.Fprintln(w, "    "+line)
+		}
+	}
+	fmt.Fprintln(w, "\nOptions")
+	fmt.Fprint(w, wrapText("severity: off, warning or error, under rules."+r.ID+" in "+configFileName+".", "  ", "    ", width))
+	for _, opt := range r.Options {
+		fmt.Fprint(w, wrapText(opt.Name+": "+opt.Description, "  ", "    ", width))
+	}
+}
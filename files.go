@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// defaultIgnoreDirs lists directory names skipped while walking.
+var defaultIgnoreDirs = []string{"vendor", ".git"}
+
+// walker expands the command line arguments into the files to validate,
+// leaving out the ones the ignore files and --exclude select.
+type walker struct {
+	// ignoreDirs are the directory names given with --ignore.
+	ignoreDirs []string
+	// exclude holds the --exclude patterns, applied after the ignore files.
+	exclude ignoreRules
+	// noIgnore turns off every way of skipping files.
+	noIgnore bool
+	// skipped counts the manifests left out.
+	skipped int
+}
+
+// expandArgs turns the command line arguments into the list of files to
+// validate. Directories are walked recursively for *.yaml, *.yml and *.json
+// files and glob patterns (including **) are expanded, both honoring the
+// ignore files found on the way. URLs and anything else are passed through
+// unchanged, the latter so that read errors are reported for it.
+func (w *walker) expandArgs(args []string) []string {
+	var files []string
+	for _, arg := range args {
+		if isURL(arg) {
+			files = append(files, arg)
+			continue
+		}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			files = append(files, w.walkDir(arg, nil, map[string]bool{}, isManifest)...)
+			continue
+		}
+		if strings.ContainsAny(arg, "*?[") {
+			if matches := w.expandGlob(arg); len(matches) > 0 {
+				files = append(files, matches...)
+				continue
+			}
+		}
+		files = append(files, arg)
+	}
+	return files
+}
+
+// isManifest reports whether p has a YAML or JSON file extension.
+func isManifest(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// skipDir reports whether a directory should not be walked because of its
+// name.
+func (w *walker) skipDir(name string) bool {
+	if w.noIgnore {
+		return false
+	}
+	if strings.HasPrefix(name, ".") && name != "." && name != ".." {
+		return true
+	}
+	for _, ig := range w.ignoreDirs {
+		if name == strings.TrimSuffix(ig, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// walkDir returns the files below dir for which keep returns true, leaving
+// out those excluded by rules, the ignore files of dir and the directories
+// below it, and --exclude. Symlinked directories are followed, and visited
+// records resolved paths so that symlink loops end.
+func (w *walker) walkDir(dir string, rules ignoreRules, visited map[string]bool, keep func(p string) bool) []string {
+	real, err := filepath.EvalSymlinks(dir)
+	if err == nil {
+		real, err = filepath.Abs(real)
+	}
+	if err != nil || visited[real] {
+		return nil
+	}
+	visited[real] = true
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	if !w.noIgnore {
+		rules = append(rules[:len(rules):len(rules)], loadIgnoreFile(dir)...)
+	}
+	var files []string
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if !w.skipDir(e.Name()) && !w.ignored(rules, p, true) {
+				files = append(files, w.walkDir(p, rules, visited, keep)...)
+			}
+		} else if keep(p) {
+			if w.ignored(rules, p, false) {
+				w.skipped++
+				continue
+			}
+			files = append(files, relPath(p))
+		}
+	}
+	return files
+}
+
+// ignored reports whether rules or --exclude leave out p.
+func (w *walker) ignored(rules ignoreRules, p string, isDir bool) bool {
+	if w.noIgnore {
+		return false
+	}
+	return rules.ignored(p, isDir) || w.exclude.ignored(p, isDir)
+}
+
+// expandGlob returns the files matching pattern. Unlike filepath.Glob it
+// supports ** to match any number of directories.
+func (w *walker) expandGlob(pattern string) []string {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	return w.walkDir(globBase(pattern), nil, map[string]bool{}, func(p string) bool {
+		return globMatch(pattern, filepath.ToSlash(p))
+	})
+}
+
+// globBase returns the longest directory prefix of a slash-separated
+// pattern that holds no glob characters, which is where matching starts.
+func globBase(pattern string) string {
+	segs := strings.Split(pattern, "/")
+	for i, seg := range segs {
+		if strings.ContainsAny(seg, "*?[") {
+			if i == 0 {
+				return "."
+			}
+			if base := strings.Join(segs[:i], "/"); base != "" {
+				return filepath.FromSlash(base)
+			}
+			return "/"
+		}
+	}
+	return "."
+}
+
+// globMatch matches a slash-separated path against a pattern in which **
+// matches zero or more path segments.
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(path.Clean(pattern), "/"), strings.Split(path.Clean(name), "/"))
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], segs[0]); err != nil || !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// relPath returns p relative to the current directory when p lies below it.
+func relPath(p string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return p
+}
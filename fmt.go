@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-test-maga/validator"
+)
+
+// fmtFiles is the fmt subcommand, which prints manifests in canonical form,
+// or with -w rewrites them, or with --check lists those that are not.
+func fmtFiles(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := fs.Bool("check", false, "print a diff for each file that is not in canonical form and exit with 1, changing nothing")
+	write := fs.Bool("w", false, "rewrite the files in place instead of printing them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s fmt [flags] <file|dir|->...\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Indents by two spaces, puts apiVersion, kind, metadata and spec first and only")
+		fmt.Fprintln(os.Stderr, "quotes strings that need it, keeping comments, anchors and document order.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 || *check && *write {
+		fs.Usage()
+		return exitUsage
+	}
+	walk := &walker{ignoreDirs: defaultIgnoreDirs}
+	code := exitClean
+	for _, filePath := range walk.expandArgs(fs.Args()) {
+		var data []byte
+		var err error
+		if filePath == "-" {
+			data, err = readStdin(validator.DefaultMaxBytes)
+		} else {
+			data, err = os.ReadFile(filePath)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			code = exitInputError
+			continue
+		}
+		formatted, err := validator.Format(filePath, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s in %s: %v\n", syntaxName(err), filePath, err)
+			code = exitInputError
+			continue
+		}
+		switch {
+		case *check:
+			if diff := unifiedDiff("a/"+filepath.ToSlash(filePath), "b/"+filepath.ToSlash(filePath), string(data), string(formatted)); diff != "" {
+				fmt.Print(diff)
+				code = max(code, exitFindings)
+			}
+		case *write && filePath != "-":
+			if string(formatted) == string(data) {
+				continue
+			}
+			if err := writeFile(filePath, formatted); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", filePath, err)
+				code = exitInputError
+			}
+		default:
+			os.Stdout.Write(formatted)
+		}
+	}
+	return code
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-test-maga/validator"
+)
+
+// get runs the get subcommand, which prints the values at a field path of a
+// manifest, and returns the exit code: exitFindings when nothing matches.
+func get(args []string) int {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	withPosition := fs.Bool("with-position", false, "print the file, line and column of each value before it")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s get [flags] <yaml-file|-> <path>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Paths look like spec.containers[0].image; quote keys with dots as")
+		fmt.Fprintln(os.Stderr, "metadata.labels['app.kubernetes.io/name'] or metadata.labels.\"app.kubernetes.io/name\",")
+		fmt.Fprintln(os.Stderr, "and use * or [*] to match every key or item.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	filePath, path := fs.Arg(0), fs.Arg(1)
+	var data []byte
+	var err error
+	if filePath == "-" {
+		data, err = readStdin(validator.DefaultMaxBytes)
+	} else {
+		data, err = os.ReadFile(filePath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return exitInputError
+	}
+	matches, err := validator.Query(filePath, data, path)
+	if errors.Is(err, validator.ErrInvalidPath) {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	for _, m := range matches {
+		if *withPosition {
+			fmt.Printf("%s:%d:%d %s:", filePath, m.Line, m.Column, m.Path)
+			if m.Document > 0 {
+				fmt.Printf(" [document %d]", m.Document)
+			}
+			if strings.Contains(m.Value, "\n") {
+				fmt.Println()
+			} else {
+				fmt.Print(" ")
+			}
+		}
+		fmt.Println(m.Value)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s in %s: %v\n", syntaxName(err), filePath, err)
+		return exitInputError
+	}
+	if len(matches) == 0 {
+		return exitFindings
+	}
+	return exitClean
+}
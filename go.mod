@@ -2,4 +2,8 @@ module go-test-maga
 
 go 1.22.12
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/sys v0.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
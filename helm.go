@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+)
+
+// stripTemplates makes a Helm chart template parseable as YAML by replacing
+// its {{ ... }} actions, keeping every byte offset so that positions still
+// match the original file. An action sharing its line with other content is
+// replaced by a placeholder scalar padded with spaces; lines holding
+// nothing but actions, such as {{- if .Values.x }}, are blanked. The lines
+// touched by an action are returned, since the values found there are not
+// the real ones.
+func stripTemplates(data []byte) ([]byte, map[int]bool) {
+	inAction := make([]bool, len(data))
+	var starts []int
+	for pos := 0; ; {
+		start := bytes.Index(data[pos:], []byte("{{"))
+		if start < 0 {
+			break
+		}
+		start += pos
+		// An unterminated action runs to the end of the file
+		end := len(data)
+		if n := bytes.Index(data[start+2:], []byte("}}")); n >= 0 {
+			end = start + 2 + n + 2
+		}
+		for i := start; i < end; i++ {
+			inAction[i] = true
+		}
+		starts = append(starts, start)
+		pos = end
+	}
+
+	out := bytes.Clone(data)
+	templated := map[int]bool{}
+	if len(starts) == 0 {
+		return out, templated
+	}
+	line, lineStart := 1, 0
+	for eol := 0; eol <= len(data); eol++ {
+		if eol < len(data) && data[eol] != '\n' {
+			continue
+		}
+		content, touched := false, false
+		for i := lineStart; i < eol; i++ {
+			if inAction[i] {
+				touched = true
+			} else if c := data[i]; c != ' ' && c != '\t' && c != '\r' {
+				content = true
+			}
+		}
+		if touched {
+			templated[line] = true
+			for i := lineStart; i < eol; i++ {
+				if inAction[i] {
+					out[i] = ' '
+				}
+			}
+			if content {
+				// Leave a scalar where the value was
+				for _, s := range starts {
+					if s >= lineStart && s < eol {
+						out[s] = 'x'
+					}
+				}
+			}
+		}
+		line, lineStart = line+1, eol+1
+	}
+	return out, templated
+}
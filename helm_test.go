@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHelmTemplates(t *testing.T) {
+	cfgs, err := newConfigs("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := checkFile("testdata/chart/templates/deployment.yaml", &inputOptions{stdinName: "-", helm: true}, cfgs)
+	var reported, suppressed []string
+	for _, f := range res.findings {
+		s := fmt.Sprintf("%d:%d %s", f.Line, f.Column, f.RuleID)
+		if f.Suppressed {
+			suppressed = append(suppressed, s)
+		} else {
+			reported = append(reported, s)
+		}
+	}
+	// The literal fields are validated as usual
+	if want := []string{"21:26 image-pull-policy", "23:26 container-ports", "34:21 memory-quantity"}; !slices.Equal(reported, want) {
+		t.Errorf("reported findings = %v, want %v", reported, want)
+	}
+	// and whatever is found on a templated line is left out, since the
+	// placeholder standing for the value is not the rendered one
+	templated := []string{"1:", "5:", "9:", "20:", "27:", "30:", "35:"}
+	for _, s := range suppressed {
+		if !slices.ContainsFunc(templated, func(p string) bool { return strings.HasPrefix(s, p) }) {
+			t.Errorf("finding %s on a literal line was suppressed", s)
+		}
+	}
+	if len(suppressed) == 0 {
+		t.Error("no finding on the templated lines; the placeholders should trip the rules")
+	}
+}
+
+func TestStripTemplates(t *testing.T) {
+	src := "{{- if .Values.on }}\nimage: \"{{ .Values.repo }}:{{ .Values.tag }}\"\nport: {{ .Values.port }} # the port\nname: web\n{{- end }}\n"
+	out, templated := stripTemplates([]byte(src))
+	// A placeholder takes the place of an action, padded to its length
+	x := func(action string) string { return "x" + strings.Repeat(" ", len(action)-1) }
+	want := strings.Repeat(" ", len("{{- if .Values.on }}")) + "\n" +
+		"image: \"" + x("{{ .Values.repo }}") + ":" + x("{{ .Values.tag }}") + "\"\n" +
+		"port: " + x("{{ .Values.port }}") + " # the port\n" +
+		"name: web\n" +
+		strings.Repeat(" ", len("{{- end }}")) + "\n"
+	if string(out) != want {
+		t.Errorf("stripTemplates =\n%q\nwant\n%q", out, want)
+	}
+	if len(out) != len(src) {
+		t.Errorf("stripTemplates changed the length from %d to %d", len(src), len(out))
+	}
+	for line, want := range map[int]bool{1: true, 2: true, 3: true, 4: false, 5: true} {
+		if templated[line] != want {
+			t.Errorf("templated[%d] = %v, want %v", line, templated[line], want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the file listing, in gitignore syntax, the paths below
+// its directory that are not validated.
+const ignoreFileName = ".podlintignore"
+
+// ignoreRule is one pattern of an ignore file or of --exclude.
+type ignoreRule struct {
+	// base is the absolute directory the pattern is relative to.
+	base    string
+	pattern string
+	// anchored patterns contain a slash and match from base only; the
+	// others match a name at any depth.
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// ignoreRules are applied in order, the last matching rule deciding, as in
+// gitignore.
+type ignoreRules []ignoreRule
+
+// parseIgnoreRules parses gitignore-style patterns relative to base.
+func parseIgnoreRules(data []byte, base string) ignoreRules {
+	var rules ignoreRules
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rule, ok := newIgnoreRule(line, base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func newIgnoreRule(line, base string) (ignoreRule, bool) {
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: abs}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// \# and \! stand for a leading # or !
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	rule.pattern = strings.TrimPrefix(line, "/")
+	return rule, rule.pattern != ""
+}
+
+// loadIgnoreFile returns the rules of the ignore file in dir, if any.
+func loadIgnoreFile(dir string) ignoreRules {
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFileName))
+	if err != nil {
+		return nil
+	}
+	return parseIgnoreRules(data, dir)
+}
+
+// ignored reports whether the rules exclude p, a directory if isDir is set.
+func (rules ignoreRules) ignored(p string, isDir bool) bool {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		pattern := rule.pattern
+		if !rule.anchored {
+			pattern = "**/" + pattern
+		}
+		// Patterns use slashes on every platform
+		if globMatch(pattern, filepath.ToSlash(rel)) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
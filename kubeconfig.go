@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clusterTimeout bounds each request made to the API server.
+const clusterTimeout = 30 * time.Second
+
+// kubeconfig is the part of a kubeconfig file needed to reach the API
+// server of the current context.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string   `yaml:"name"`
+		User kubeUser `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+type kubeUser struct {
+	Token                 string     `yaml:"token"`
+	TokenFile             string     `yaml:"tokenFile"`
+	ClientCertificate     string     `yaml:"client-certificate"`
+	ClientCertificateData string     `yaml:"client-certificate-data"`
+	ClientKey             string     `yaml:"client-key"`
+	ClientKeyData         string     `yaml:"client-key-data"`
+	Username              string     `yaml:"username"`
+	Password              string     `yaml:"password"`
+	Exec                  *yaml.Node `yaml:"exec"`
+	AuthProvider          *yaml.Node `yaml:"auth-provider"`
+}
+
+// kubeCluster is an API server with the client and credentials to call it.
+type kubeCluster struct {
+	server string
+	client *http.Client
+	token  string
+	// username and password are set for basic authentication.
+	username, password string
+}
+
+func (c *kubeCluster) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// loadKubeconfig reads the first file of $KUBECONFIG, or ~/.kube/config,
+// and returns the cluster of its current context. Credentials come from a
+// token or a client certificate; exec and auth-provider plugins are not
+// run.
+func loadKubeconfig() (*kubeCluster, error) {
+	path := os.Getenv("KUBECONFIG")
+	if path != "" {
+		path = filepath.SplitList(path)[0]
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".kube", "config")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg kubeconfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.CurrentContext == "" {
+		return nil, fmt.Errorf("%s: no current-context is set", path)
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range cfg.Contexts {
+		if c.Name == cfg.CurrentContext {
+			clusterName, userName, found = c.Context.Cluster, c.Context.User, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: context %q not found", path, cfg.CurrentContext)
+	}
+	// Relative file names in a kubeconfig are relative to its directory
+	resolve := func(name string) string {
+		if name == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(filepath.Dir(path), name)
+	}
+
+	cluster := &kubeCluster{}
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, c := range cfg.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		cluster.server = strings.TrimSuffix(c.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := fileOrData(resolve(c.Cluster.CertificateAuthority), c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: cluster %s: %v", path, clusterName, err)
+		}
+		if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("%s: cluster %s: no certificates in certificate-authority", path, clusterName)
+			}
+		}
+	}
+	if !found || cluster.server == "" {
+		return nil, fmt.Errorf("%s: cluster %q not found", path, clusterName)
+	}
+
+	for _, u := range cfg.Users {
+		if u.Name != userName {
+			continue
+		}
+		user := u.User
+		cluster.token = user.Token
+		if user.TokenFile != "" {
+			token, err := os.ReadFile(resolve(user.TokenFile))
+			if err != nil {
+				return nil, fmt.Errorf("%s: user %s: %v", path, userName, err)
+			}
+			cluster.token = strings.TrimSpace(string(token))
+		}
+		cluster.username, cluster.password = user.Username, user.Password
+		cert, err := fileOrData(resolve(user.ClientCertificate), user.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: user %s: %v", path, userName, err)
+		}
+		key, err := fileOrData(resolve(user.ClientKey), user.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: user %s: %v", path, userName, err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("%s: user %s: %v", path, userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		if cluster.token == "" && cluster.username == "" && cert == nil && (user.Exec != nil || user.AuthProvider != nil) {
+			return nil, fmt.Errorf("%s: user %s authenticates with a plugin, which is not supported; use a token or a client certificate", path, userName)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	cluster.client = &http.Client{Timeout: clusterTimeout, Transport: transport}
+	return cluster, nil
+}
+
+// fileOrData returns the contents of a kubeconfig field given either as a
+// file name or as base64 data, or nil if neither is set.
+func fileOrData(name, data string) ([]byte, error) {
+	if data != "" {
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, errors.New("invalid base64 data")
+		}
+		return b, nil
+	}
+	if name != "" {
+		return os.ReadFile(name)
+	}
+	return nil, nil
+}
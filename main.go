@@ -1,143 +1,574 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"go-test-maga/validator"
 )
 
+// Exit codes. When several apply, the highest wins.
+const (
+	exitClean      = 0
+	exitFindings   = 1 // at least one error finding, or too many warnings
+	exitInputError = 2 // a file could not be read, parsed or configured
+	exitUsage      = 3 // bad command line
+)
+
+const exitCodeHelp = `
+Exit codes:
+  0  no error findings
+  1  error findings, or more warnings than --max-warnings
+  2  a file could not be read or parsed, or its configuration is invalid
+  3  bad command line usage
+`
+
+// subcommands are run in place of validating files when named by the first
+// argument.
+var subcommands = map[string]func(args []string) int{
+	"completion": completion,
+	"diff":       diff,
+	"explain":    explain,
+	"fmt":        fmtFiles,
+	"get":        get,
+	"serve":      serve,
+	"version":    versionCommand,
+	"webhook":    webhook,
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <yaml-file>\n", os.Args[0])
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		if os.Args[1] == completeCommand {
+			if code, done := complete(os.Args[2:]); done {
+				os.Exit(code)
+			}
+		} else if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+	stdinName := flag.String("stdin-filename", "-", "file name to report for input read from stdin")
+	ignore := flag.String("ignore", strings.Join(defaultIgnoreDirs, ","), "comma-separated directory names to skip when walking directories")
+	color := flag.String("color", "auto", "colorize text output: always, never or auto")
+	configPath := flag.String("config", "", "configuration file to use instead of the nearest "+configFileName)
+	enable := flag.String("enable", "", "comma-separated rule IDs to enable even if a configuration file turns them off")
+	disable := flag.String("disable", "", "comma-separated rule IDs to disable")
+	exclude := flag.String("exclude", "", "comma-separated gitignore-style patterns of files to skip when walking directories and globs")
+	noIgnore := flag.Bool("no-ignore", false, "validate every file, ignoring "+ignoreFileName+" files, --exclude and --ignore")
+	reportUnused := flag.Bool("report-unused-ignores", false, "report lint-ignore comments that silence no finding")
+	listRules := flag.Bool("list-rules", false, "print the available rules and exit")
+	showVersion := flag.Bool("version", false, "print the version and build information and exit, as JSON with --format json")
+	showSource := flag.Bool("show-source", false, "print the offending source line under each text finding")
+	maxWarnings := flag.Int("max-warnings", -1, "fail when there are more than this many warnings (-1 for no limit)")
+	strict := flag.Bool("strict", false, "treat warnings as errors")
+	preset := flag.String("preset", "", "comma-separated rule presets to turn on: security, references")
+	checkReferences := flag.Bool("check-references", false, "check that the objects referred to by Services and pods are among the files checked, as the references preset does")
+	kinds := flag.String("kinds", "", "comma-separated kinds to validate, skipping documents of other kinds")
+	skipKinds := flag.String("skip-kinds", "", "comma-separated kinds of documents to skip")
+	targetKubeVersion := flag.String("target-kube-version", "", "Kubernetes version, such as 1.29, to check apiVersions against (default the newest)")
+	skipCharacterScan := flag.Bool("skip-character-scan", false, "do not scan files for indenting tabs, non-breaking spaces and other invisible characters, as for generated files")
+	strictFields := flag.Bool("strict-fields", false, "report keys that are not fields of the object's type")
+	maxBytes := flag.Int64("max-bytes", 0, fmt.Sprintf("largest input accepted, in bytes (default the configuration file's maxBytes, or %d)", validator.DefaultMaxBytes))
+	maxNodes := flag.Int("max-nodes", 0, fmt.Sprintf("most nodes a document may hold once its aliases are expanded (default the configuration file's maxNodes, or %d)", validator.DefaultMaxNodes))
+	maxDepth := flag.Int("max-depth", 0, fmt.Sprintf("deepest a document may nest (default the configuration file's maxDepth, or %d)", validator.DefaultMaxDepth))
+	maxErrors := flag.Int("max-errors", 0, "stop reporting after this many findings in total (0 for no limit)")
+	maxPerFile := flag.Int("max-errors-per-file", 0, "report at most this many findings per file (0 for no limit)")
+	jobs := flag.Int("jobs", runtime.GOMAXPROCS(0), "number of files to check in parallel")
+	quiet := flag.Bool("quiet", false, "print only the summary, not the individual findings")
+	noSummary := flag.Bool("no-summary", false, "do not print the summary")
+	watch := flag.Bool("watch", false, "keep running and check files again as they change")
+	helm := flag.Bool("helm", false, "read the files as Helm chart templates, ignoring the values of {{ ... }} actions")
+	urlTimeout := flag.Duration("url-timeout", 10*time.Second, "timeout for fetching manifests from URLs")
+	urlMaxBytes := flag.Int64("url-max-bytes", 10<<20, "largest manifest fetched from a URL, in bytes")
+	insecure := flag.Bool("insecure-skip-tls-verify", false, "do not verify TLS certificates when fetching URLs")
+	baselinePath := flag.String("baseline", "", "do not report the findings recorded in this baseline file")
+	pruneBaseline := flag.Bool("prune-baseline", false, "remove the entries matching no finding from the --baseline file")
+	writeBaselinePath := flag.String("write-baseline", "", "record the current findings in this baseline file")
+	diffBase := flag.String("diff-base", "", "only report findings on lines changed since this git ref, such as origin/main")
+	changedLinesFrom := flag.String("changed-lines-from", "", "only report findings on lines added by this unified diff file")
+	fix := flag.Bool("fix", false, "rewrite files in place, correcting the findings that have a safe fix")
+	fixDryRun := flag.Bool("fix-dry-run", false, "print the changes --fix would make as a unified diff instead of making them")
+	schemaDir := flag.String("schema-dir", "", "check objects against the OpenAPI v3 JSON documents in this directory")
+	schemaFromCluster := flag.Bool("schema-from-cluster", false, "check objects against the OpenAPI v3 schema of the current kubeconfig context's cluster")
+	schemaCacheTTL := flag.Duration("schema-cache-ttl", 24*time.Hour, "how long a schema downloaded by --schema-from-cluster is reused (0 to always download)")
+	printMetrics := flag.Bool("print-metrics", false, "print the metrics of the run, in the Prometheus text format, to stderr before exiting")
+	format := flag.String("format", "text", "output format: "+formatNames()+" (github is the default inside GitHub Actions)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <yaml-file|dir|glob|url|->...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s completion bash|zsh|fish\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s diff [flags] <old-file|-> <new-file|->\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s explain [flags] [rule-id]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s fmt [flags] <file|dir|->...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s get [flags] <yaml-file|-> <path>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve|webhook [flags]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s version [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(os.Stderr, exitCodeHelp)
 	}
-	filePath := os.Args[1]
-	data, err := os.ReadFile(filePath)
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if code, ok := parseFlags(flag.CommandLine, os.Args[1:]); !ok {
+		os.Exit(code)
+	}
+	if *listRules {
+		printRules(os.Stdout)
+		return
+	}
+	if *showVersion {
+		os.Exit(printVersion(*format))
+	}
+	// The github format is only the default where no flag needs the text
+	// format: --fix-dry-run prints a diff and --watch reports as it goes
+	if os.Getenv("GITHUB_ACTIONS") != "" && !flagSet("format") && !*fixDryRun && !*watch {
+		*format = "github"
+	}
+	writer, ok := writers[*format]
+	if !ok && *format != "text" {
+		fmt.Fprintf(os.Stderr, "unknown format %q, want one of %s\n", *format, formatNames())
+		os.Exit(exitUsage)
+	}
+	if *fixDryRun && flagSet("format") && writer != nil {
+		fmt.Fprintln(os.Stderr, "--fix-dry-run prints a diff and needs the text format")
+		os.Exit(exitUsage)
+	}
+	paint, err := colorEnabled(*color, os.Stderr)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
-
-	var root yaml.Node
-	if err := yaml.Unmarshal(data, &root); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing YAML: %v\n", err)
-		os.Exit(1)
+	cfgs, err := newConfigs(*configPath, *enable, *disable)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
-
-	// Determine root mapping node
-	var mapping *yaml.Node
-	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
-		mapping = root.Content[0]
-	} else {
-		mapping = &root
+	cfgs.reportUnused = *reportUnused
+	if *skipCharacterScan {
+		cfgs.disable = append(cfgs.disable, "invisible-characters")
 	}
-
-	var errs []string
-
-	// Find spec node and validate fields
-	specNode := findMapKey(mapping, "spec")
-	if specNode != nil && specNode.Kind == yaml.MappingNode {
-		// Validate spec.os
-		errs = append(errs, validateOS(specNode, filePath)...)
-
-		// Validate each container in spec.containers
-		conts := findMapKey(specNode, "containers")
-		if conts != nil && conts.Kind == yaml.SequenceNode {
-			for _, contNode := range conts.Content {
-				if contNode.Kind != yaml.MappingNode {
-					continue
+	cfgs.strictFields = *strictFields
+	cfgs.kinds, cfgs.skipKinds = splitList(*kinds), splitList(*skipKinds)
+	cfgs.presets = splitList(*preset)
+	if *checkReferences {
+		cfgs.presets = append(cfgs.presets, "references")
+	}
+	if *maxBytes < 0 || *maxNodes < 0 || *maxDepth < 0 {
+		fmt.Fprintln(os.Stderr, "--max-bytes, --max-nodes and --max-depth must not be negative")
+		os.Exit(exitUsage)
+	}
+	cfgs.maxBytes, cfgs.maxNodes, cfgs.maxDepth = *maxBytes, *maxNodes, *maxDepth
+	for _, p := range cfgs.presets {
+		if err := validator.CheckPreset(p); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	if *targetKubeVersion != "" {
+		if _, err := validator.ParseKubeVersion(*targetKubeVersion); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+		cfgs.targetKubeVersion = *targetKubeVersion
+	}
+	if *schemaDir != "" && *schemaFromCluster {
+		fmt.Fprintln(os.Stderr, "--schema-dir and --schema-from-cluster cannot be combined")
+		os.Exit(exitUsage)
+	}
+	if *schemaDir != "" || *schemaFromCluster {
+		var err error
+		if *schemaDir != "" {
+			cfgs.schema, err = loadSchemaDir(*schemaDir)
+		} else {
+			cfgs.schema, err = loadClusterSchema(*schemaCacheTTL)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading schema: %v\n", err)
+			os.Exit(exitInputError)
+		}
+	}
+	args := flag.Args()
+	if len(args) < 1 {
+		// Read a piped manifest when no files are given
+		if info, err := os.Stdin.Stat(); *watch || err != nil || info.Mode()&os.ModeCharDevice != 0 {
+			flag.Usage()
+			os.Exit(exitUsage)
+		}
+		args = []string{"-"}
+	}
+	if *watch && (*format != "text" || slices.ContainsFunc(args, func(arg string) bool { return arg == "-" || isURL(arg) })) {
+		fmt.Fprintln(os.Stderr, "--watch needs local file arguments and the text format")
+		os.Exit(exitUsage)
+	}
+	// Annotations are not shown in the job log, so print the text too
+	printText := (*format == "text" || *format == "github") && !*quiet
+	start := time.Now()
+	walk := &walker{ignoreDirs: strings.Split(*ignore, ","), noIgnore: *noIgnore}
+	for _, pattern := range splitList(*exclude) {
+		if rule, ok := newIgnoreRule(pattern, "."); ok {
+			walk.exclude = append(walk.exclude, rule)
+		}
+	}
+	files := walk.expandArgs(args)
+	rep := &report{Findings: []validator.Finding{}, Rules: map[string][]string{}}
+	var all []validator.Finding
+	suppressed, unchanged, skippedDocs := 0, 0, 0
+	for _, filePath := range files {
+		if filePath == "-" {
+			rep.Files = append(rep.Files, *stdinName)
+		} else {
+			rep.Files = append(rep.Files, filePath)
+		}
+	}
+	var base *baseline
+	if *baselinePath != "" {
+		if base, err = loadBaseline(*baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	var changes *changedLines
+	switch {
+	case *diffBase != "" && *changedLinesFrom != "":
+		fmt.Fprintln(os.Stderr, "--diff-base and --changed-lines-from cannot be combined")
+		os.Exit(exitUsage)
+	case *diffBase != "":
+		if changes, err = gitChangedLines(*diffBase); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading changes since %s: %v\n", *diffBase, err)
+			os.Exit(exitUsage)
+		}
+	case *changedLinesFrom != "":
+		f, err := os.Open(*changedLinesFrom)
+		if err == nil {
+			changes, err = parseUnifiedDiff(f)
+			f.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *changedLinesFrom, err)
+			os.Exit(exitUsage)
+		}
+	}
+	var current map[baselineKey]int
+	if *writeBaselinePath != "" {
+		current = map[baselineKey]int{}
+	}
+	in := &inputOptions{stdinName: *stdinName, helm: *helm, fix: *fix || *fixDryRun, fixDryRun: *fixDryRun, client: newHTTPClient(*urlTimeout, *insecure), maxURLBytes: *urlMaxBytes}
+	check := func(filePath string) fileResult {
+		return checkFile(filePath, in, cfgs)
+	}
+	// keep drops the suppressed findings of a file and those outside the
+	// changed lines, and applies --strict
+	keep := func(res fileResult) []validator.Finding {
+		findings, n := dropSuppressed(res.findings)
+		suppressed += n
+		if changes != nil {
+			findings, n = changes.dropUnchanged(findings)
+			unchanged += n
+		}
+		if *strict {
+			for i := range findings {
+				findings[i].Severity = validator.SeverityError
+			}
+		}
+		return findings
+	}
+	printFindings := func(findings []validator.Finding, data []byte) {
+		lines := strings.Split(string(data), "\n")
+		// Print errors to stderr
+		for _, f := range findings {
+			fmt.Fprintln(os.Stderr, formatText(f, paint))
+			if *showSource && f.Line > 0 {
+				fmt.Fprint(os.Stderr, formatSource(lines, f.Line, f.Column))
+			}
+		}
+	}
+	byFile := map[string][]validator.Finding{}
+	emit := func(res fileResult) {
+		fmt.Print(res.diff)
+		if res.fixed > 0 && !*quiet {
+			fmt.Fprintf(os.Stderr, "Fixed %d problems in %s\n", res.fixed, res.file)
+		}
+		if base != nil || current != nil {
+			lines := strings.Split(string(res.data), "\n")
+			if base != nil {
+				base.match(res.findings, lines)
+			}
+			for _, f := range res.findings {
+				if current != nil && !f.Suppressed && !inputRules[f.RuleID] {
+					current[findingKey(f, lines)]++
 				}
-				// readinessProbe.httpGet.port validation
-				errs = append(errs, validateHTTPGetPort(contNode, filePath)...)
-				// resources.requests.cpu validation
-				errs = append(errs, validateCPU(contNode, filePath)...)
 			}
 		}
+		skippedDocs += res.skippedDocs
+		if res.rules != nil {
+			rep.Rules[res.file] = res.rules
+		}
+		findings := keep(res)
+		all = append(all, findings...)
+		if len(findings) > 0 {
+			byFile[findings[0].File] = append(byFile[findings[0].File], findings...)
+		}
+		findings = rep.limit(findings, *maxErrors, *maxPerFile)
+		if printText {
+			printFindings(findings, res.data)
+		}
+		rep.Findings = append(rep.Findings, findings...)
+	}
+	// Objects are related to each other once every file is checked, so the
+	// results are held until then and each file is emitted once, with the
+	// findings of those rules merged into its own. The contents of the files
+	// are only kept when the findings are matched to their lines.
+	var objects []*validator.Object
+	var results []fileResult
+	keepContents := base != nil || current != nil || *showSource
+	checkFiles(files, *jobs, check, func(res fileResult) {
+		objects = append(objects, res.objects...)
+		res.objects = nil
+		if !keepContents {
+			res.data = nil
+		}
+		results = append(results, res)
+	})
+	related := map[string][]validator.Finding{}
+	for _, f := range validator.CheckRelations(objects) {
+		related[f.File] = append(related[f.File], f)
+	}
+	for _, res := range results {
+		if fs := related[res.file]; len(fs) > 0 {
+			res.findings = validator.SortFindings(append(res.findings, fs...))
+		}
+		emit(res)
+	}
+	rep.summarize(all, suppressed, time.Since(start))
+	rep.Summary.Skipped = walk.skipped
+	rep.Summary.SkippedDocuments = skippedDocs
+	rep.Summary.Unchanged = unchanged
+	if writer != nil {
+		if err := writer(os.Stdout, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(exitInputError)
+		}
+	}
+	if printText && rep.Omitted > 0 {
+		fmt.Fprintf(os.Stderr, "... and %d more\n", rep.Omitted)
+	}
+	if base != nil {
+		if stale := base.stale(rep.Files); len(stale) > 0 && *pruneBaseline {
+			if err := writeBaseline(*baselinePath, base.pruned(rep.Files)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing baseline: %v\n", err)
+				os.Exit(exitInputError)
+			}
+			fmt.Fprintf(os.Stderr, "Removed %d stale entries from %s\n", len(stale), *baselinePath)
+		} else if len(stale) > 0 {
+			for _, e := range stale {
+				fmt.Fprintf(os.Stderr, "%s: stale baseline entry for %s (%s) matches no finding\n", *baselinePath, e.File, e.RuleID)
+			}
+		}
+	}
+	if current != nil {
+		if err := writeBaseline(*writeBaselinePath, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing baseline: %v\n", err)
+			os.Exit(exitInputError)
+		}
+	}
+	// The JSON output carries the summary itself
+	if !*noSummary && *format != "json" {
+		fmt.Fprintln(os.Stderr, rep.Summary)
 	}
+	if *printMetrics {
+		if err := validator.WriteMetrics(os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		}
+	}
+	if !*watch {
+		os.Exit(exitCode(all, *maxWarnings))
+	}
+	w, err := newWatcher(args, files, walk, byFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+		os.Exit(exitInputError)
+	}
+	recheck := func(filePath string) []validator.Finding {
+		res := check(filePath)
+		findings := keep(res)
+		if !*quiet {
+			printFindings(findings, res.data)
+		}
+		return findings
+	}
+	os.Exit(w.run(paint, recheck, func(files []string, all []validator.Finding, elapsed time.Duration) {
+		if !*noSummary {
+			rep := &report{Files: files}
+			rep.summarize(all, 0, elapsed)
+			fmt.Fprintln(os.Stderr, rep.Summary)
+		}
+	}, *maxWarnings))
+}
 
-	// Print errors to stderr
-	for _, e := range errs {
-		fmt.Fprintln(os.Stderr, e)
+// exitCode returns the exit code for the findings of a run.
+func exitCode(findings []validator.Finding, maxWarnings int) int {
+	errors, warnings := 0, 0
+	for _, f := range findings {
+		switch {
+		case inputRules[f.RuleID]:
+			return exitInputError
+		case f.Severity == validator.SeverityWarning:
+			warnings++
+		default:
+			errors++
+		}
 	}
-	if len(errs) > 0 {
-		os.Exit(1)
+	if errors > 0 || (maxWarnings >= 0 && warnings > maxWarnings) {
+		return exitFindings
 	}
+	return exitClean
 }
 
-func findMapKey(node *yaml.Node, key string) *yaml.Node {
-	if node == nil || node.Kind != yaml.MappingNode {
-		return nil
+// printRules writes a table of every rule with its default severity.
+func printRules(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tSEVERITY\tDESCRIPTION")
+	for _, r := range validator.Rules() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.ID, r.Severity, r.Description)
 	}
-	// Mapping node Content has [key0, val0, key1, val1, ...]
-	for i := 0; i < len(node.Content); i += 2 {
-		k := node.Content[i]
-		if k.Kind == yaml.ScalarNode && k.Value == key {
-			return node.Content[i+1]
+	tw.Flush()
+}
+
+// dropSuppressed removes the findings silenced by lint-ignore comments and
+// returns how many there were.
+func dropSuppressed(findings []validator.Finding) ([]validator.Finding, int) {
+	kept := findings[:0]
+	for _, f := range findings {
+		if !f.Suppressed {
+			kept = append(kept, f)
 		}
 	}
-	return nil
+	return kept, len(findings) - len(kept)
 }
 
-func validateOS(specNode *yaml.Node, filename string) []string {
-	var errs []string
-	osNode := findMapKey(specNode, "os")
-	if osNode != nil {
-		if osNode.Kind == yaml.ScalarNode {
-			if osNode.Value != "linux" && osNode.Value != "windows" {
-				errs = append(errs, fmt.Sprintf("%s:%d os has unsupported value '%s'", filename, osNode.Line, osNode.Value))
-			}
-		} else if osNode.Kind == yaml.MappingNode {
-			nameNode := findMapKey(osNode, "name")
-			if nameNode == nil {
-				errs = append(errs, fmt.Sprintf("%s:%d os.name is required", filename, osNode.Line))
-			} else if nameNode.Kind != yaml.ScalarNode {
-				errs = append(errs, fmt.Sprintf("%s:%d os.name must be string", filename, nameNode.Line))
-			} else if nameNode.Value != "linux" && nameNode.Value != "windows" {
-				errs = append(errs, fmt.Sprintf("%s:%d os has unsupported value '%s'", filename, nameNode.Line, nameNode.Value))
-			}
-		} else {
-			errs = append(errs, fmt.Sprintf("%s:%d os must be string or object", filename, osNode.Line))
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-	}
-	return errs
+	})
+	return set
 }
 
-func validateHTTPGetPort(contNode *yaml.Node, filename string) []string {
-	var errs []string
-	rpNode := findMapKey(contNode, "readinessProbe")
-	if rpNode != nil && rpNode.Kind == yaml.MappingNode {
-		httpGetNode := findMapKey(rpNode, "httpGet")
-		if httpGetNode != nil && httpGetNode.Kind == yaml.MappingNode {
-			portNode := findMapKey(httpGetNode, "port")
-			if portNode != nil && portNode.Kind == yaml.ScalarNode {
-				// Parse port as int and check range
-				portVal, err := strconv.Atoi(portNode.Value)
-				if err != nil || portVal < 1 || portVal > 65535 {
-					errs = append(errs, fmt.Sprintf("%s:%d port value out of range", filename, portNode.Line))
-				}
+// readStdin reads stdin up to one byte past limit, enough for the
+// validator to reject larger input without it all being held in memory.
+func readStdin(limit int64) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(os.Stdin, limit+1))
+}
+
+// inputOptions says how checkFile reads its input.
+type inputOptions struct {
+	// stdinName is the file name reported for input read from stdin.
+	stdinName string
+	// helm is set when the input holds Helm chart templates.
+	helm bool
+	// fix applies the fixes of the rules to local files, writing them back
+	// unless fixDryRun is set.
+	fix, fixDryRun bool
+	// client fetches the files given as URLs, up to maxURLBytes long.
+	client      *http.Client
+	maxURLBytes int64
+}
+
+// checkFile reads and validates a single file, or stdin when filePath is
+// "-", and returns its findings together with its contents. Read and parse
+// failures are returned as findings without a line so the remaining files
+// are still checked.
+func checkFile(filePath string, in *inputOptions, cfgs *configs) fileResult {
+	fail := func(name, rule, message string) fileResult {
+		return fileResult{file: name, findings: []validator.Finding{fileError(name, rule, message)}}
+	}
+	cfg, err := cfgs.forFile(filePath)
+	if err != nil {
+		name := filePath
+		if name == "-" {
+			name = in.stdinName
+		}
+		return fail(name, "config-error", fmt.Sprintf("Error loading configuration: %v", err))
+	}
+	var data []byte
+	local := false
+	switch {
+	case filePath == "-":
+		filePath = in.stdinName
+		data, err = readStdin(cfg.ByteLimit())
+	case isURL(filePath):
+		if data, err = fetchURL(in.client, filePath, in.maxURLBytes); err != nil {
+			return fail(filePath, "read-error", fmt.Sprintf("Error fetching %s: %v", filePath, err))
+		}
+	default:
+		local = true
+		data, err = os.ReadFile(filePath)
+	}
+	if err != nil {
+		return fail(filePath, "read-error", fmt.Sprintf("Error reading file: %v", err))
+	}
+	res := fileResult{file: filePath, data: data}
+	// Templates would not survive being re-encoded
+	if in.fix && local && !in.helm {
+		fixed, n, err := validator.Fix(filePath, data, cfg)
+		switch {
+		case err != nil || n == 0:
+		case in.fixDryRun:
+			res.diff = unifiedDiff("a/"+filepath.ToSlash(filePath), "b/"+filepath.ToSlash(filePath), string(data), string(fixed))
+		default:
+			if err := writeFile(filePath, fixed); err != nil {
+				return fail(filePath, "write-error", fmt.Sprintf("Error writing fixed file: %v", err))
 			}
+			res.data, res.fixed = fixed, n
 		}
 	}
-	return errs
+	input, templated := res.data, map[int]bool(nil)
+	if in.helm {
+		input, templated = stripTemplates(res.data)
+	}
+	vres, err := validator.ValidateStream(filePath, input, cfg)
+	res.findings, res.skippedDocs, res.objects = vres.Findings, vres.SkippedDocuments, vres.Objects
+	res.rules = cfg.EnabledRules()
+	if errors.Is(err, validator.ErrLimit) {
+		res.findings = append(res.findings, fileError(filePath, "input-limit", fmt.Sprintf("Error checking %s: %v", filePath, err)))
+	} else if err != nil {
+		res.findings = append(res.findings, fileError(filePath, "parse-error", fmt.Sprintf("Error parsing %s in %s: %v", syntaxName(err), filePath, err)))
+	}
+	for i := range res.findings {
+		// The value on a templated line is only known once rendered
+		if templated[res.findings[i].Line] {
+			res.findings[i].Suppressed = true
+		}
+	}
+	return res
 }
 
-func validateCPU(contNode *yaml.Node, filename string) []string {
-	var errs []string
-	resNode := findMapKey(contNode, "resources")
-	if resNode != nil && resNode.Kind == yaml.MappingNode {
-		for _, resType := range []string{"limits", "requests"} {
-			section := findMapKey(resNode, resType)
-			if section != nil && section.Kind == yaml.MappingNode {
-				cpuNode := findMapKey(section, "cpu")
-				if cpuNode != nil && cpuNode.Kind == yaml.ScalarNode {
-					if cpuNode.Tag != "!!int" {
-						errs = append(errs, fmt.Sprintf("%s:%d cpu must be int", filename, cpuNode.Line))
-					}
-				}
-			}
-		}
+// writeFile replaces the contents of an existing file, keeping its mode.
+func writeFile(filePath string, data []byte) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
 	}
-	return errs
+	return os.WriteFile(filePath, data, info.Mode().Perm())
+}
+
+// syntaxName names what input was read as, given the error parsing it.
+func syntaxName(err error) string {
+	if errors.Is(err, validator.ErrJSON) {
+		return "JSON"
+	}
+	return "YAML"
+}
+
+func fileError(filePath, rule, message string) validator.Finding {
+	return validator.Finding{File: filePath, RuleID: rule, Severity: validator.SeverityError, Message: message}
 }
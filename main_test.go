@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// binary is the validator built for the tests that run it.
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "yamlvalidator")
+	if err != nil {
+		panic(err)
+	}
+	binary = filepath.Join(dir, "yamlvalidator")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		panic("go build: " + err.Error() + "\n" + string(out))
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// run runs the validator with args in the directory testdata, with stdin
+// read from stdin, and returns its exit code and output.
+func run(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = "testdata"
+	cmd.Stdin = strings.NewReader(stdin)
+	// A run inside GitHub Actions would change the default format
+	cmd.Env = append(os.Environ(), "GITHUB_ACTIONS=")
+	out, err := cmd.CombinedOutput()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	return cmd.ProcessState.ExitCode(), string(out)
+}
+
+func TestExitCodes(t *testing.T) {
+	clean, err := os.ReadFile("testdata/clean.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  int
+	}{
+		{"clean file", "", []string{"clean.yaml"}, exitClean},
+		{"warnings only", "", []string{"warnings.yaml"}, exitClean},
+		{"clean stdin", string(clean), []string{"-"}, exitClean},
+		{"error findings", "", []string{"failing.yaml"}, exitFindings},
+		{"clean and failing", "", []string{"clean.yaml", "failing.yaml"}, exitFindings},
+		{"too many warnings", "", []string{"--max-warnings", "0", "warnings.yaml"}, exitFindings},
+		{"strict", "", []string{"--strict", "warnings.yaml"}, exitFindings},
+		{"error rule disabled", "", []string{"--disable", "object-header,image-pull-policy,container-ports,cpu-quantity", "failing.yaml"}, exitClean},
+		{"invalid YAML", "", []string{"broken.yaml"}, exitInputError},
+		{"missing file", "", []string{"missing.yaml"}, exitInputError},
+		{"parse error beats findings", "", []string{"failing.yaml", "broken.yaml"}, exitInputError},
+		{"input over the byte limit", "", []string{"--max-bytes", "10", "clean.yaml"}, exitInputError},
+		{"unknown flag", "", []string{"--no-such-flag", "clean.yaml"}, exitUsage},
+		{"unknown format", "", []string{"--format", "yaml", "clean.yaml"}, exitUsage},
+		{"unknown rule", "", []string{"--disable", "no-such-rule", "clean.yaml"}, exitUsage},
+		{"unknown preset", "", []string{"--preset", "none", "clean.yaml"}, exitUsage},
+		{"help", "", []string{"--help"}, exitClean},
+		{"unknown subcommand flag", "", []string{"explain", "--no-such-flag"}, exitUsage},
+		{"negative server limit", "", []string{"serve", "--max-nodes", "-1"}, exitUsage},
+		{"negative webhook limit", "", []string{"webhook", "--tls-cert-file", "c.pem", "--tls-key-file", "k.pem", "--max-body-bytes", "-1"}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, out := run(t, tt.stdin, tt.args...); code != tt.want {
+				t.Errorf("exit code %d, want %d; output:\n%s", code, tt.want, out)
+			}
+		})
+	}
+}
+
+// TestRelatedFindings checks that the findings relating objects to each
+// other, known only once every file is checked, are merged into those of
+// their file rather than printed after them.
+func TestRelatedFindings(t *testing.T) {
+	want := []string{"related.yaml:9 duplicate-object", "related.yaml:11 labels", "failing.yaml:4 object-header"}
+
+	_, out := run(t, "", "--no-summary", "related.yaml", "failing.yaml")
+	var got []string
+	for _, m := range regexp.MustCompile(`(?m)^(\S+?):(\d+):\d+ .*\((\S+)\)$`).FindAllStringSubmatch(out, -1) {
+		got = append(got, m[1]+":"+m[2]+" "+m[3])
+	}
+	if len(got) < 3 || !slices.Equal(got[:3], want) {
+		t.Errorf("text output starts %q, want %q", got, want)
+	}
+
+	_, out = run(t, "", "--format", "json", "related.yaml", "failing.yaml")
+	var rep struct {
+		Findings []struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+			Rule string `json:"rule"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(out), &rep); err != nil {
+		t.Fatalf("decoding the JSON output: %v\n%s", err, out)
+	}
+	got = nil
+	for _, f := range rep.Findings[:3] {
+		got = append(got, fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Rule))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("JSON findings start %q, want %q", got, want)
+	}
+
+	// The cap applies to the file as a whole
+	_, out = run(t, "", "--no-summary", "--max-errors-per-file", "1", "related.yaml")
+	if n := len(regexp.MustCompile(`(?m)^related\.yaml:`).FindAllString(out, -1)); n != 1 || !strings.Contains(out, "... and 1 more") {
+		t.Errorf("--max-errors-per-file 1 printed %d findings:\n%s", n, out)
+	}
+}
+
+func TestParseErrorNamesFormat(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"broken.json"}, "Error parsing JSON in broken.json: json: line 2"},
+		{[]string{"broken.yaml"}, "Error parsing YAML in broken.yaml: yaml: "},
+		{[]string{"get", "broken.json", "kind"}, "Error parsing JSON in broken.json: "},
+		{[]string{"diff", "broken.json", "clean.yaml"}, "Error parsing JSON: "},
+	}
+	for _, tt := range tests {
+		if _, out := run(t, "", tt.args...); !strings.Contains(out, tt.want) {
+			t.Errorf("%v printed\n%s\nwant it to hold %q", tt.args, out, tt.want)
+		}
+	}
+}
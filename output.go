@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go-test-maga/validator"
+	"go-test-maga/version"
+)
+
+// formatText renders a finding as "file:line:column message", with the
+// document index of multi-document streams, a severity label, and the
+// field path and rule ID at the end. Findings without a line, such as read
+// errors, are rendered as the bare message.
+func formatText(f validator.Finding, p painter) string {
+	if f.Line == 0 {
+		return p.paint(ansiRed, f.Message)
+	}
+	var b strings.Builder
+	b.WriteString(p.paint(ansiBold, f.File))
+	b.WriteString(p.paint(ansiDim, fmt.Sprintf(":%d:%d", f.Line, f.Column)))
+	b.WriteString(" ")
+	if f.Document > 0 {
+		fmt.Fprintf(&b, "[document %d] ", f.Document)
+	}
+	if f.Severity == validator.SeverityWarning {
+		b.WriteString(p.paint(ansiYellow, "warning:") + " ")
+	} else {
+		b.WriteString(p.paint(ansiRed, "error:") + " ")
+	}
+	b.WriteString(f.Message)
+	if showPath(f) {
+		b.WriteString(" " + p.paint(ansiDim, "at "+f.Path))
+	}
+	if f.RuleID != "" {
+		b.WriteString(" " + p.paint(ansiCyan, "("+f.RuleID+")"))
+	}
+	return b.String()
+}
+
+// showPath reports whether the field path of f should follow its message,
+// which is the case unless the message already starts with it.
+func showPath(f validator.Finding) bool {
+	return f.Path != "" && !strings.HasPrefix(f.Message, f.Path)
+}
+
+// withPath returns the message of f followed by its field path, for the
+// formats without a field of their own for it.
+func withPath(f validator.Finding) string {
+	if !showPath(f) {
+		return f.Message
+	}
+	return f.Message + " at " + f.Path
+}
+
+// writeJSON writes all findings of the run and its summary as a single JSON
+// object.
+func writeJSON(w io.Writer, r *report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Findings  []validator.Finding `json:"findings"`
+		Summary   summary             `json:"summary"`
+		Truncated bool                `json:"truncated,omitempty"`
+	}{r.Findings, r.Summary, r.Truncated})
+}
+
+// inputRules are the fileRules that mean a file could not be checked.
+var inputRules = map[string]bool{"read-error": true, "parse-error": true, "config-error": true, "write-error": true, "input-limit": true}
+
+// fileRules describes the findings the CLI itself reports when a file
+// cannot be checked.
+var fileRules = []validator.Rule{
+	{ID: "read-error", Description: "The file could not be read", Severity: validator.SeverityError},
+	{ID: "parse-error", Description: "The file is not valid YAML", Severity: validator.SeverityError},
+	{ID: "input-limit", Description: "The file exceeds --max-bytes, --max-nodes or --max-depth", Severity: validator.SeverityError},
+	{ID: "config-error", Description: "The configuration file for the file could not be loaded", Severity: validator.SeverityError},
+	{ID: "write-error", Description: "The file could not be written back by --fix", Severity: validator.SeverityError},
+}
+
+// toolName is the name reports give the validator.
+const toolName = "yamlvalidator"
+
+// The subset of SARIF 2.1.0 written by writeSARIF.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		Results    []sarifResult `json:"results"`
+		Properties *struct {
+			Truncated bool `json:"truncated"`
+		} `json:"properties,omitempty"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name    string      `json:"name"`
+		Version string      `json:"version"`
+		Rules   []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLogicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *sarifRegion `json:"region,omitempty"`
+		} `json:"physicalLocation"`
+		LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s validator.Severity) string {
+	switch s {
+	case validator.SeverityError:
+		return "error"
+	case validator.SeverityWarning:
+		return "warning"
+	}
+	return "note"
+}
+
+// writeSARIF writes all findings of the run as a SARIF 2.1.0 log with a
+// single run whose rules are taken from the rule registry.
+func writeSARIF(w io.Writer, r *report) error {
+	driver := sarifDriver{Name: toolName, Version: version.Get().Version, Rules: []sarifRule{}}
+	index := map[string]int{}
+	for _, rule := range append(validator.Rules(), fileRules...) {
+		sr := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{rule.Description}}
+		sr.DefaultConfiguration.Level = sarifLevel(rule.Severity)
+		index[rule.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sr)
+	}
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, f := range r.Findings {
+		res := sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: index[f.RuleID],
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{f.Message},
+		}
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = artifactURI(f.File)
+		if f.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+		}
+		if f.Path != "" {
+			loc.LogicalLocations = []sarifLogicalLocation{{f.Path}}
+		}
+		res.Locations = []sarifLocation{loc}
+		run.Results = append(run.Results, res)
+	}
+	if r.Truncated {
+		run.Properties = &struct {
+			Truncated bool `json:"truncated"`
+		}{true}
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// artifactURI turns a file path into a SARIF artifact URI: relative paths
+// stay relative to the working directory, absolute ones become file URIs.
+func artifactURI(path string) string {
+	if filepath.IsAbs(path) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(path)}).String()
+}
+
+// writers holds the structured output formats, which write the report of
+// the whole run to stdout at once.
+var writers = map[string]func(w io.Writer, r *report) error{
+	"json":       writeJSON,
+	"sarif":      writeSARIF,
+	"junit":      writeJUnit,
+	"github":     writeGitHub,
+	"checkstyle": writeCheckstyle,
+}
+
+// formatNames lists the accepted values of --format.
+func formatNames() string {
+	names := []string{"text"}
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return strings.Join(names, ", ")
+}
+
+// The JUnit XML layout understood by Jenkins and GitLab.
+type (
+	junitTestSuites struct {
+		XMLName   xml.Name         `xml:"testsuites"`
+		Tests     int              `xml:"tests,attr"`
+		Failures  int              `xml:"failures,attr"`
+		Timestamp string           `xml:"timestamp,attr"`
+		Truncated bool             `xml:"truncated,attr,omitempty"`
+		Suites    []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name       string          `xml:"name,attr"`
+		Tests      int             `xml:"tests,attr"`
+		Failures   int             `xml:"failures,attr"`
+		Timestamp  string          `xml:"timestamp,attr"`
+		Properties []junitProperty `xml:"properties>property"`
+		Cases      []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure"`
+	}
+	junitProperty struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// junitLine describes a finding inside a failure: its position and
+// message, with the field path where the message does not name it.
+func junitLine(f validator.Finding) string {
+	var b strings.Builder
+	if f.Document > 0 {
+		fmt.Fprintf(&b, "document %d, ", f.Document)
+	}
+	if f.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", f.Line, f.Column)
+	}
+	b.WriteString(withPath(f))
+	return b.String()
+}
+
+// writeJUnit writes one testsuite per file and one testcase per rule run on
+// it, besides the checks that the file could be read and parsed. A rule
+// with findings becomes a failing testcase listing all of them.
+func writeJUnit(w io.Writer, r *report) error {
+	byFile := map[string]map[string][]validator.Finding{}
+	for _, f := range r.Findings {
+		if byFile[f.File] == nil {
+			byFile[f.File] = map[string][]validator.Finding{}
+		}
+		byFile[f.File][f.RuleID] = append(byFile[f.File][f.RuleID], f)
+	}
+	now := time.Now().UTC().Format("2006-01-02T15:04:05")
+	toolVersion := version.Get().Version
+	suites := junitTestSuites{Timestamp: now, Truncated: r.Truncated}
+	for _, file := range r.Files {
+		suite := junitTestSuite{Name: file, Timestamp: now, Properties: []junitProperty{{Name: toolName + ".version", Value: toolVersion}}}
+		ids := slices.Clone(r.Rules[file])
+		for _, rule := range fileRules {
+			ids = append(ids, rule.ID)
+		}
+		// Findings of rules outside the list, if any, are not lost
+		var others []string
+		for id := range byFile[file] {
+			if !slices.Contains(ids, id) {
+				others = append(others, id)
+			}
+		}
+		sort.Strings(others)
+		for _, id := range append(ids, others...) {
+			tc := junitTestCase{Name: id, ClassName: file}
+			if hits := byFile[file][id]; len(hits) > 0 {
+				var lines []string
+				for _, f := range hits {
+					lines = append(lines, junitLine(f))
+				}
+				tc.Failure = &junitFailure{Message: lines[0], Type: string(hits[0].Severity), Text: strings.Join(lines, "\n")}
+				if len(hits) > 1 {
+					tc.Failure.Message = fmt.Sprintf("%d findings, first: %s", len(hits), lines[0])
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHub writes a workflow command per finding so GitHub Actions
+// annotates the offending lines.
+func writeGitHub(w io.Writer, r *report) error {
+	for _, f := range r.Findings {
+		command := "error"
+		if f.Severity == validator.SeverityWarning {
+			command = "warning"
+		}
+		props := []string{"file=" + escapeProperty(f.File)}
+		if f.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", f.Line))
+		}
+		if f.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", f.Column))
+		}
+		if f.RuleID != "" {
+			props = append(props, "title="+escapeProperty(f.RuleID))
+		}
+		message := withPath(f)
+		if f.Document > 0 {
+			message = fmt.Sprintf("[document %d] %s", f.Document, message)
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeData(message)); err != nil {
+			return err
+		}
+	}
+	if r.Truncated {
+		if _, err := fmt.Fprintf(w, "::notice::%d more findings were not reported\n", r.Omitted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command, which
+// additionally must not contain the separators : and ,.
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
+
+// The checkstyle XML layout read by reviewdog and editor plugins.
+type (
+	checkstyleReport struct {
+		XMLName   xml.Name         `xml:"checkstyle"`
+		Version   string           `xml:"version,attr"`
+		Truncated bool             `xml:"truncated,attr,omitempty"`
+		Files     []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// writeCheckstyle writes a file element for every file checked, including
+// the clean ones, with an error element per finding.
+func writeCheckstyle(w io.Writer, r *report) error {
+	byFile := map[string][]checkstyleError{}
+	for _, f := range r.Findings {
+		message := withPath(f)
+		if f.Document > 0 {
+			message = fmt.Sprintf("[document %d] %s", f.Document, message)
+		}
+		byFile[f.File] = append(byFile[f.File], checkstyleError{
+			Line:     f.Line,
+			Column:   f.Column,
+			Severity: string(f.Severity),
+			Message:  message,
+			Source:   f.RuleID,
+		})
+	}
+	doc := checkstyleReport{Version: "4.3", Truncated: r.Truncated}
+	for _, file := range r.Files {
+		doc.Files = append(doc.Files, checkstyleFile{Name: file, Errors: byFile[file]})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
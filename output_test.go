@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"go-test-maga/validator"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkReport checks the files of testdata as the CLI does with no flags
+// and returns the report of the run.
+func checkReport(t *testing.T, names ...string) *report {
+	t.Helper()
+	cfgs, err := newConfigs("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := &inputOptions{stdinName: "-"}
+	rep := &report{Findings: []validator.Finding{}, Rules: map[string][]string{}}
+	var all []validator.Finding
+	suppressed := 0
+	for _, name := range names {
+		res := checkFile(filepath.ToSlash(filepath.Join("testdata", name)), in, cfgs)
+		rep.Files = append(rep.Files, res.file)
+		rep.Rules[res.file] = res.rules
+		findings, n := dropSuppressed(res.findings)
+		suppressed += n
+		all = append(all, findings...)
+		rep.Findings = append(rep.Findings, findings...)
+	}
+	rep.summarize(all, suppressed, 0)
+	return rep
+}
+
+// render writes rep in format.
+func render(t *testing.T, format string, rep *report) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writers[format](&buf, rep); err != nil {
+		t.Fatalf("writing %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+// checkGolden compares got with the golden file name of testdata, or
+// rewrites the file when the test runs with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the test with -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; run the test with -update to accept it\n%s", path, unifiedDiff(path, "got", string(want), string(got)))
+	}
+}
+
+// The JUnit report carries the time of the run and the version of the
+// tool, which differ from one run to the next.
+var junitVolatile = regexp.MustCompile(`(timestamp|value)="[^"]*"`)
+
+func TestJUnitGolden(t *testing.T) {
+	out := render(t, "junit", checkReport(t, "clean.yaml", "failing.yaml"))
+	checkGolden(t, "junit.golden.xml", junitVolatile.ReplaceAll(out, []byte(`$1="-"`)))
+}
+
+func TestJUnitListsOnlyRulesRun(t *testing.T) {
+	rep := checkReport(t, "clean.yaml")
+	out := string(render(t, "junit", rep))
+	ran := rep.Rules["testdata/clean.yaml"]
+	if len(ran) == 0 {
+		t.Fatal("no rules recorded for the file")
+	}
+	for _, r := range validator.Rules() {
+		listed := strings.Contains(out, `<testcase name="`+r.ID+`"`)
+		if want := slices.Contains(ran, r.ID); listed != want {
+			t.Errorf("rule %s: listed %v, want %v", r.ID, listed, want)
+		}
+		// The rules of presets are off by default
+		if r.Preset != "" && listed {
+			t.Errorf("rule %s of the %s preset is listed but did not run", r.ID, r.Preset)
+		}
+	}
+}
+
+// The driver version is that of the build.
+var sarifVolatile = regexp.MustCompile(`("name": "` + toolName + `",\s+"version": )"[^"]*"`)
+
+func TestSARIFGolden(t *testing.T) {
+	rep := checkReport(t, "clean.yaml", "failing.yaml")
+	rules := map[string]bool{}
+	for _, f := range rep.Findings {
+		rules[f.RuleID] = true
+	}
+	if len(rules) < 3 {
+		t.Fatalf("the fixtures hit %d rules, want at least 3", len(rules))
+	}
+	out := render(t, "sarif", rep)
+	checkSARIF(t, out)
+	checkGolden(t, "sarif.golden.json", sarifVolatile.ReplaceAll(out, []byte(`$1"-"`)))
+}
+
+// checkSARIF checks a log against the constraints of the SARIF 2.1.0
+// schema on the properties writeSARIF writes: required properties, enums,
+// minimums, and no properties the schema does not define.
+func checkSARIF(t *testing.T, data []byte) {
+	t.Helper()
+	var log map[string]any
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	levels := []string{"none", "note", "warning", "error"}
+	// fields checks that obj, at path, holds the required properties and
+	// no properties outside allowed
+	fields := func(path string, obj any, required []string, allowed ...string) map[string]any {
+		m, ok := obj.(map[string]any)
+		if !ok {
+			t.Fatalf("%s is %T, want an object", path, obj)
+		}
+		for _, k := range required {
+			if _, ok := m[k]; !ok {
+				t.Errorf("%s lacks the required property %s", path, k)
+			}
+		}
+		for k := range m {
+			if !slices.Contains(required, k) && !slices.Contains(allowed, k) {
+				t.Errorf("%s has the property %s, which the schema does not allow", path, k)
+			}
+		}
+		return m
+	}
+	text := func(path string, v any) string {
+		s, ok := v.(string)
+		if !ok {
+			t.Errorf("%s is %T, want a string", path, v)
+		}
+		return s
+	}
+	fields("log", log, []string{"version", "runs"}, "$schema", "properties")
+	if log["version"] != "2.1.0" {
+		t.Errorf("version = %v, want 2.1.0", log["version"])
+	}
+	runs, _ := log["runs"].([]any)
+	if len(runs) != 1 {
+		t.Fatalf("%d runs, want 1", len(runs))
+	}
+	run := fields("runs[0]", runs[0], []string{"tool"}, "results", "properties")
+	tool := fields("tool", run["tool"], []string{"driver"})
+	driver := fields("driver", tool["driver"], []string{"name"}, "version", "rules")
+	if text("driver.name", driver["name"]) == "" {
+		t.Error("driver.name is empty")
+	}
+	rules, _ := driver["rules"].([]any)
+	var ids []string
+	for i, r := range rules {
+		path := fmt.Sprintf("rules[%d]", i)
+		rule := fields(path, r, []string{"id"}, "shortDescription", "defaultConfiguration")
+		ids = append(ids, text(path+".id", rule["id"]))
+		desc := fields(path+".shortDescription", rule["shortDescription"], []string{"text"})
+		text(path+".shortDescription.text", desc["text"])
+		conf := fields(path+".defaultConfiguration", rule["defaultConfiguration"], nil, "level")
+		if l := text(path+".defaultConfiguration.level", conf["level"]); !slices.Contains(levels, l) {
+			t.Errorf("%s.defaultConfiguration.level = %q", path, l)
+		}
+	}
+	results, ok := run["results"].([]any)
+	if !ok || len(results) == 0 {
+		t.Fatal("the run has no results")
+	}
+	for i, r := range results {
+		path := fmt.Sprintf("results[%d]", i)
+		res := fields(path, r, []string{"message"}, "ruleId", "ruleIndex", "level", "locations")
+		msg := fields(path+".message", res["message"], []string{"text"})
+		if text(path+".message.text", msg["text"]) == "" {
+			t.Errorf("%s.message.text is empty", path)
+		}
+		if l := text(path+".level", res["level"]); !slices.Contains(levels, l) {
+			t.Errorf("%s.level = %q", path, l)
+		}
+		// ruleIndex must point at the rule named by ruleId
+		index, _ := res["ruleIndex"].(float64)
+		if int(index) < 0 || int(index) >= len(ids) || ids[int(index)] != res["ruleId"] {
+			t.Errorf("%s.ruleIndex %v does not point at rule %v", path, res["ruleIndex"], res["ruleId"])
+		}
+		locs, _ := res["locations"].([]any)
+		for j, l := range locs {
+			lpath := fmt.Sprintf("%s.locations[%d]", path, j)
+			loc := fields(lpath, l, nil, "physicalLocation", "logicalLocations")
+			phys := fields(lpath+".physicalLocation", loc["physicalLocation"], nil, "artifactLocation", "region")
+			art := fields(lpath+".artifactLocation", phys["artifactLocation"], nil, "uri")
+			if _, err := url.Parse(text(lpath+".artifactLocation.uri", art["uri"])); err != nil {
+				t.Errorf("%s.artifactLocation.uri: %v", lpath, err)
+			}
+			if phys["region"] != nil {
+				region := fields(lpath+".region", phys["region"], nil, "startLine", "startColumn")
+				for _, k := range []string{"startLine", "startColumn"} {
+					if v, ok := region[k]; ok && v.(float64) < 1 {
+						t.Errorf("%s.region.%s = %v, want at least 1", lpath, k, v)
+					}
+				}
+			}
+			logical, _ := loc["logicalLocations"].([]any)
+			for k, ll := range logical {
+				fields(fmt.Sprintf("%s.logicalLocations[%d]", lpath, k), ll, nil, "fullyQualifiedName")
+			}
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	rep := checkReport(t, "clean.yaml", "failing.yaml")
+	var decoded struct {
+		Findings []validator.Finding `json:"findings"`
+		Summary  summary             `json:"summary"`
+	}
+	if err := json.Unmarshal(render(t, "json", rep), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Findings) != len(rep.Findings) || len(rep.Findings) == 0 {
+		t.Fatalf("decoded %d findings, want %d", len(decoded.Findings), len(rep.Findings))
+	}
+	for i, got := range decoded.Findings {
+		want := rep.Findings[i]
+		if got.File != want.File || got.Line != want.Line || got.Column != want.Column || got.Path != want.Path ||
+			got.RuleID != want.RuleID || got.Severity != want.Severity || got.Message != want.Message ||
+			got.Document != want.Document || got.Suppressed != want.Suppressed {
+			t.Errorf("finding %d decoded as %+v, want %+v", i, got, want)
+		}
+	}
+	if decoded.Summary != rep.Summary {
+		t.Errorf("summary decoded as %+v, want %+v", decoded.Summary, rep.Summary)
+	}
+}
+
+// orderKey identifies a finding in the output of a format.
+func orderKey(file string, line, column int, rule, message string) string {
+	return fmt.Sprintf("%s:%d:%d %s %s", file, line, column, rule, message)
+}
+
+func TestFormatsKeepFindingOrder(t *testing.T) {
+	rep := checkReport(t, "interleaved.yaml", "failing.yaml")
+	var want []string
+	for _, f := range rep.Findings {
+		want = append(want, orderKey(f.File, f.Line, f.Column, f.RuleID, f.Message))
+	}
+	// Files come in the order they were named
+	sorted := slices.Clone(rep.Findings)
+	slices.SortStableFunc(sorted, func(a, b validator.Finding) int {
+		return cmp.Or(cmp.Compare(slices.Index(rep.Files, a.File), slices.Index(rep.Files, b.File)),
+			cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column), cmp.Compare(a.RuleID, b.RuleID))
+	})
+	if !slices.EqualFunc(sorted, rep.Findings, func(a, b validator.Finding) bool { return a.Message == b.Message && a.File == b.File }) {
+		t.Fatal("the findings of a file are not in file, line, column and rule order")
+	}
+	rules := map[string]bool{}
+	for _, f := range rep.Findings {
+		rules[f.RuleID] = true
+	}
+	if len(rules) < 5 {
+		t.Fatalf("the fixtures hit %d rules, want findings of several interleaving", len(rules))
+	}
+
+	check := func(format string, got []string) {
+		t.Helper()
+		if !slices.Equal(got, want) {
+			t.Errorf("%s order:\n  %s\nwant:\n  %s", format, strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+		}
+	}
+	var text []string
+	for _, f := range rep.Findings {
+		line := formatText(f, false)
+		if !strings.HasPrefix(line, fmt.Sprintf("%s:%d:%d ", f.File, f.Line, f.Column)) {
+			t.Fatalf("text line %q does not start with the position of the finding", line)
+		}
+		text = append(text, orderKey(f.File, f.Line, f.Column, f.RuleID, f.Message))
+	}
+	check("text", text)
+
+	var decoded struct {
+		Findings []validator.Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(render(t, "json", rep), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range decoded.Findings {
+		got = append(got, orderKey(f.File, f.Line, f.Column, f.RuleID, f.Message))
+	}
+	check("json", got)
+
+	var log sarifLog
+	if err := json.Unmarshal(render(t, "sarif", rep), &log); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, res := range log.Runs[0].Results {
+		loc := res.Locations[0].PhysicalLocation
+		got = append(got, orderKey(loc.ArtifactLocation.URI, loc.Region.StartLine, loc.Region.StartColumn, res.RuleID, res.Message.Text))
+	}
+	check("sarif", got)
+
+	// JUnit groups the findings by file and rule, keeping their order
+	// within a failure
+	var suites junitTestSuites
+	if err := xml.Unmarshal(render(t, "junit", rep), &suites); err != nil {
+		t.Fatal(err)
+	}
+	for _, suite := range suites.Suites {
+		for _, tc := range suite.Cases {
+			var lines []string
+			for _, f := range rep.Findings {
+				if f.File == suite.Name && f.RuleID == tc.Name {
+					lines = append(lines, junitLine(f))
+				}
+			}
+			if tc.Failure == nil {
+				if len(lines) > 0 {
+					t.Errorf("junit: %s %s passes but has %d findings", suite.Name, tc.Name, len(lines))
+				}
+				continue
+			}
+			if got := strings.Split(tc.Failure.Text, "\n"); !slices.Equal(got, lines) {
+				t.Errorf("junit: %s %s lists:\n  %s\nwant:\n  %s", suite.Name, tc.Name, strings.Join(got, "\n  "), strings.Join(lines, "\n  "))
+			}
+		}
+	}
+}
+
+func TestFormatsAreDeterministic(t *testing.T) {
+	for _, format := range []string{"json", "sarif", "junit", "checkstyle", "github"} {
+		first := render(t, format, checkReport(t, "interleaved.yaml", "failing.yaml", "clean.yaml"))
+		for i := 0; i < 5; i++ {
+			again := render(t, format, checkReport(t, "interleaved.yaml", "failing.yaml", "clean.yaml"))
+			if format == "junit" {
+				first, again = junitVolatile.ReplaceAll(first, nil), junitVolatile.ReplaceAll(again, nil)
+			}
+			if !bytes.Equal(first, again) {
+				t.Fatalf("%s output differs between runs", format)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+
+	"go-test-maga/validator"
+)
+
+// fileResult is the outcome of checking one file.
+type fileResult struct {
+	// file is the name the findings are reported under.
+	file     string
+	findings []validator.Finding
+	data     []byte
+	// fixed counts the fixes applied with --fix, and diff holds the changes
+	// --fix-dry-run would make.
+	fixed int
+	diff  string
+	// skippedDocs counts the documents left out by --kinds and --skip-kinds.
+	skippedDocs int
+	// objects holds the objects of the file for validator.CheckRelations.
+	objects []*validator.Object
+	// rules holds the IDs of the rules run on the file, and is nil when it
+	// could not be checked.
+	rules []string
+}
+
+// checkFiles runs check over files with up to jobs workers and hands each
+// result to emit in the order of files, so output does not depend on which
+// worker finishes first.
+func checkFiles(files []string, jobs int, check func(filePath string) fileResult, emit func(r fileResult)) {
+	results := make([]fileResult, len(files))
+	done := make([]chan struct{}, len(files))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = check(files[i])
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			next <- i
+		}
+		close(next)
+	}()
+	for i := range files {
+		<-done[i]
+		emit(results[i])
+		// Let the file contents be collected once printed
+		results[i] = fileResult{}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkValidateFiles validates a directory of generated manifests with
+// growing numbers of workers.
+func BenchmarkValidateFiles(b *testing.B) {
+	const n = 200
+	dir := b.TempDir()
+	files := make([]string, n)
+	for i := range files {
+		files[i] = filepath.Join(dir, fmt.Sprintf("deploy-%03d.yaml", i))
+		manifest := fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-%d
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web-%[1]d
+  template:
+    metadata:
+      labels:
+        app: web-%[1]d
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.27
+        ports:
+        - containerPort: 8080
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+        resources:
+          requests:
+            cpu: 100m
+            memory: 64Mi
+          limits:
+            cpu: 500m
+            memory: 128Mi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-%[1]d
+spec:
+  selector:
+    app: web-%[1]d
+  ports:
+  - port: 80
+    targetPort: 8080
+`, i)
+		if err := os.WriteFile(files[i], []byte(manifest), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	cfgs, err := newConfigs("", "", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	in := &inputOptions{stdinName: "-"}
+	check := func(filePath string) fileResult { return checkFile(filePath, in, cfgs) }
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				checkFiles(files, jobs, check, func(res fileResult) {
+					if len(res.findings) != 0 {
+						b.Fatalf("%s: %v", res.file, res.findings)
+					}
+				})
+			}
+		})
+	}
+}
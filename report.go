@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go-test-maga/validator"
+)
+
+// report is the outcome of a run, handed to the output formats.
+type report struct {
+	// Files lists every file checked, in order, so formats that list
+	// clean files can do so.
+	Files    []string
+	Findings []validator.Finding
+	// Rules holds the IDs of the rules run on each file, for formats that
+	// list the checks that passed.
+	Rules   map[string][]string
+	Summary summary
+	// Omitted counts the findings left out by --max-errors and
+	// --max-errors-per-file; Truncated is set if any were.
+	Omitted   int
+	Truncated bool
+}
+
+// limit returns the findings of one file that fit within the per-file cap
+// and what is left of the total cap, counting the rest as omitted. A cap
+// of 0 means no limit.
+func (r *report) limit(findings []validator.Finding, maxTotal, maxPerFile int) []validator.Finding {
+	n := len(findings)
+	if maxPerFile > 0 && n > maxPerFile {
+		n = maxPerFile
+	}
+	if maxTotal > 0 {
+		n = max(min(n, maxTotal-len(r.Findings)), 0)
+	}
+	if n < len(findings) {
+		r.Omitted += len(findings) - n
+		r.Truncated = true
+	}
+	return findings[:n]
+}
+
+// summary counts the outcome of a run.
+type summary struct {
+	Files             int `json:"files"`
+	FilesWithFindings int `json:"filesWithFindings"`
+	Errors            int `json:"errors"`
+	Warnings          int `json:"warnings"`
+	Suppressed        int `json:"suppressed"`
+	// Skipped counts the manifests left out by ignore files and --exclude.
+	// Ignored directories are not walked, so their files are not counted.
+	Skipped int `json:"skipped"`
+	// SkippedDocuments counts the documents left out by --kinds and
+	// --skip-kinds.
+	SkippedDocuments int `json:"skippedDocuments"`
+	// Unchanged counts the findings left out by --diff-base and
+	// --changed-lines-from for being outside the changed lines.
+	Unchanged      int     `json:"unchanged"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+}
+
+// summarize fills in the summary of r. all holds every finding, including
+// those omitted from the report.
+func (r *report) summarize(all []validator.Finding, suppressed int, elapsed time.Duration) {
+	s := summary{Files: len(r.Files), Suppressed: suppressed, ElapsedSeconds: elapsed.Seconds()}
+	withFindings := map[string]bool{}
+	for _, f := range all {
+		withFindings[f.File] = true
+		if f.Severity == validator.SeverityWarning {
+			s.Warnings++
+		} else {
+			s.Errors++
+		}
+	}
+	s.FilesWithFindings = len(withFindings)
+	r.Summary = s
+}
+
+// String renders the summary as the last line of text output.
+func (s summary) String() string {
+	text := fmt.Sprintf("%s checked, %d with findings: %s, %s", count(s.Files, "file"), s.FilesWithFindings, count(s.Errors, "error"), count(s.Warnings, "warning"))
+	if s.Suppressed > 0 {
+		text += fmt.Sprintf(", %d suppressed", s.Suppressed)
+	}
+	if s.Skipped > 0 {
+		text += fmt.Sprintf(", %s skipped", count(s.Skipped, "file"))
+	}
+	if s.SkippedDocuments > 0 {
+		text += fmt.Sprintf(", %s skipped", count(s.SkippedDocuments, "document"))
+	}
+	if s.Unchanged > 0 {
+		text += fmt.Sprintf(", %d on unchanged lines", s.Unchanged)
+	}
+	elapsed := time.Duration(s.ElapsedSeconds * float64(time.Second))
+	return fmt.Sprintf("%s (%v)", text, elapsed.Round(time.Millisecond))
+}
+
+// count renders n followed by noun, made plural unless n is 1.
+func count(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
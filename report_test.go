@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSummaryString(t *testing.T) {
+	tests := []struct {
+		s    summary
+		want string
+	}{
+		{summary{}, "0 files checked, 0 with findings: 0 errors, 0 warnings (0s)"},
+		{summary{Files: 1, FilesWithFindings: 1, Errors: 1, Warnings: 5}, "1 file checked, 1 with findings: 1 error, 5 warnings (0s)"},
+		{summary{Files: 3, FilesWithFindings: 2, Errors: 2, Warnings: 1}, "3 files checked, 2 with findings: 2 errors, 1 warning (0s)"},
+		{summary{Files: 2, Suppressed: 1, Skipped: 1, SkippedDocuments: 1, Unchanged: 1, ElapsedSeconds: 0.0123},
+			"2 files checked, 0 with findings: 0 errors, 0 warnings, 1 suppressed, 1 file skipped, 1 document skipped, 1 on unchanged lines (12ms)"},
+		{summary{Files: 4, Skipped: 2, SkippedDocuments: 3},
+			"4 files checked, 0 with findings: 0 errors, 0 warnings, 2 files skipped, 3 documents skipped (0s)"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("summary %+v renders as\n  %s\nwant\n  %s", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-test-maga/validator"
+)
+
+// maxSchemaBytes is the largest OpenAPI document read from a cluster.
+const maxSchemaBytes = 64 << 20
+
+// loadSchemaDir reads every .json file of dir as an OpenAPI v3 document,
+// such as those saved with kubectl get --raw /openapi/v3/apis/apps/v1.
+func loadSchemaDir(dir string) (*validator.Schema, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s holds no .json files", dir)
+	}
+	schema := validator.NewSchema()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.Add(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return schema, nil
+}
+
+// loadClusterSchema downloads the OpenAPI v3 documents of every API group
+// served by the cluster of the current kubeconfig context. Responses are
+// cached in the user cache directory and reused for ttl.
+func loadClusterSchema(ttl time.Duration) (*validator.Schema, error) {
+	cluster, err := loadKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+	cacheDir := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "podlint", "openapi")
+	}
+	get := func(relURL string) ([]byte, error) {
+		return cachedGet(cluster, relURL, cacheDir, ttl)
+	}
+	data, err := get("/openapi/v3")
+	if err != nil {
+		return nil, err
+	}
+	var index struct {
+		Paths map[string]struct {
+			ServerRelativeURL string `json:"serverRelativeURL"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("%s/openapi/v3: %v", cluster.server, err)
+	}
+	var groups []string
+	for p := range index.Paths {
+		if p == "api/v1" || strings.HasPrefix(p, "apis/") {
+			groups = append(groups, p)
+		}
+	}
+	sort.Strings(groups)
+	schema := validator.NewSchema()
+	for _, p := range groups {
+		data, err := get(index.Paths[p].ServerRelativeURL)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.Add(data); err != nil {
+			return nil, fmt.Errorf("%s%s: %v", cluster.server, index.Paths[p].ServerRelativeURL, err)
+		}
+	}
+	return schema, nil
+}
+
+// cachedGet returns the response of the cluster to relURL, from the cache
+// if it was stored there less than ttl ago. An empty cacheDir disables the
+// cache.
+func cachedGet(cluster *kubeCluster, relURL, cacheDir string, ttl time.Duration) ([]byte, error) {
+	sum := sha256.Sum256([]byte(cluster.server + relURL))
+	cachePath := ""
+	if cacheDir != "" && ttl > 0 {
+		cachePath = filepath.Join(cacheDir, hex.EncodeToString(sum[:16])+".json")
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < ttl {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				return data, nil
+			}
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, cluster.server+relURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	cluster.authorize(req)
+	resp, err := cluster.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s%s: unexpected status %s", cluster.server, relURL, resp.Status)
+	}
+	data, err := readLimited(resp.Body, maxSchemaBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s: %v", cluster.server, relURL, err)
+	}
+	if cachePath != "" {
+		// A cache that cannot be written only costs the next run a download
+		if os.MkdirAll(cacheDir, 0o755) == nil {
+			os.WriteFile(cachePath, data, 0o644)
+		}
+	}
+	return data, nil
+}
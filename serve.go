@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-test-maga/validator"
+)
+
+// serve runs the serve subcommand, an HTTP server validating the manifests
+// posted to it, and returns the exit code.
+func serve(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	maxBody := fs.Int64("max-body-bytes", validator.DefaultMaxBytes, "largest request body accepted, in bytes")
+	maxNodes := fs.Int("max-nodes", validator.DefaultMaxNodes, "most nodes a document may hold once its aliases are expanded")
+	maxDepth := fs.Int("max-depth", validator.DefaultMaxDepth, "deepest a document may nest")
+	configPath := fs.String("config", "", "configuration file to use instead of the nearest "+configFileName+" above the working directory")
+	enable := fs.String("enable", "", "comma-separated rule IDs to enable even if a configuration file turns them off")
+	disable := fs.String("disable", "", "comma-separated rule IDs to disable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s serve [flags]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Serves POST /validate, which validates the YAML request body, GET /healthz, and GET /metrics for Prometheus.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *maxBody < 0 || *maxNodes < 0 || *maxDepth < 0 {
+		fmt.Fprintln(os.Stderr, "--max-body-bytes, --max-nodes and --max-depth must not be negative")
+		return exitUsage
+	}
+	// 0 stands for the default, as it does in the configuration file
+	if *maxBody == 0 {
+		*maxBody = validator.DefaultMaxBytes
+	}
+	cfgs, err := newConfigs(*configPath, *enable, *disable)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// Requests are untrusted, so the limits hold whatever a configuration
+	// file says
+	cfgs.maxBytes, cfgs.maxNodes, cfgs.maxDepth = *maxBody, *maxNodes, *maxDepth
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("POST /validate", countRequests(validateHandler(cfgs, *maxBody)))
+	mux.Handle("GET /metrics", metricsHandler())
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return runServer(srv, func() error { return srv.ListenAndServe() })
+}
+
+// runServer runs srv with listen until SIGINT or SIGTERM, then waits for
+// the requests in flight to finish, and returns the exit code.
+func runServer(srv *http.Server, listen func() error) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	failed := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", srv.Addr)
+		failed <- listen()
+	}()
+	select {
+	case err := <-failed:
+		log.Print(err)
+		return exitInputError
+	case <-ctx.Done():
+	}
+	log.Print("shutting down")
+	if err := srv.Shutdown(context.Background()); err != nil {
+		log.Print(err)
+		return exitInputError
+	}
+	return exitClean
+}
+
+// validateHandler validates the manifest in the request body. It answers
+// with the JSON output format and status 200 when there are no findings,
+// 422 when there are, and 400 when the body is not valid YAML. The
+// filename query parameter names the manifest in the findings.
+func validateHandler(cfgs *configs, maxBody int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeHTTPError(w, status, fmt.Sprintf("Error reading request body: %v", err))
+			return
+		}
+		name := r.URL.Query().Get("filename")
+		if name == "" {
+			name = "-"
+		}
+		cfg, err := cfgs.forFile("-")
+		if err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("Error loading configuration: %v", err))
+			return
+		}
+		findings, err := validator.ValidateWithConfig(name, data, cfg)
+		if errors.Is(err, validator.ErrLimit) {
+			writeHTTPError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Error checking %s: %v", name, err))
+			return
+		}
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Sprintf("Error parsing %s in %s: %v", syntaxName(err), name, err))
+			return
+		}
+		findings, suppressed := dropSuppressed(findings)
+		rep := &report{Files: []string{name}, Findings: append([]validator.Finding{}, findings...)}
+		rep.summarize(findings, suppressed, time.Since(start))
+		w.Header().Set("Content-Type", "application/json")
+		if len(findings) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		writeJSON(w, rep)
+	})
+}
+
+// countRequests counts the requests served by h in the metrics of the
+// validator.
+func countRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validator.CountRequest()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// metricsHandler serves the metrics of the validator to Prometheus.
+func metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := validator.WriteMetrics(w); err != nil {
+			log.Print(err)
+		}
+	})
+}
+
+// writeHTTPError answers a request with status and a JSON error message.
+func writeHTTPError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{message})
+}
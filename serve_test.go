@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrape returns the samples served on /metrics, by series.
+func scrape(t *testing.T, url string) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := map[string]float64{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample %q", line)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	cfgs, err := newConfigs("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /validate", countRequests(validateHandler(cfgs, 1<<20)))
+	mux.Handle("GET /metrics", metricsHandler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Other tests validate too, so only the increments are compared
+	before := scrape(t, srv.URL)
+	manifest, err := os.ReadFile("testdata/failing.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL+"/validate?filename=failing.yaml", "application/yaml", strings.NewReader(string(manifest)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("POST /validate answered %d, want 422", resp.StatusCode)
+	}
+	after := scrape(t, srv.URL)
+
+	for series, want := range map[string]float64{
+		"yamlvalidator_requests_total":                                            1,
+		"yamlvalidator_documents_validated_total":                                 1,
+		"yamlvalidator_parse_failures_total":                                      0,
+		`yamlvalidator_findings_total{rule="object-header",severity="error"}`:     1,
+		`yamlvalidator_findings_total{rule="image-pull-policy",severity="error"}`: 1,
+		`yamlvalidator_findings_total{rule="container-ports",severity="error"}`:   1,
+		`yamlvalidator_findings_total{rule="cpu-quantity",severity="error"}`:      1,
+		`yamlvalidator_findings_total{rule="image-tag",severity="warning"}`:       1,
+		"yamlvalidator_validation_duration_seconds_count":                         1,
+		`yamlvalidator_validation_duration_seconds_bucket{le="+Inf"}`:             1,
+	} {
+		if got := after[series] - before[series]; got != want {
+			t.Errorf("%s went up by %v, want %v", series, got, want)
+		}
+	}
+	if after["yamlvalidator_validation_duration_seconds_sum"] <= before["yamlvalidator_validation_duration_seconds_sum"] {
+		t.Error("yamlvalidator_validation_duration_seconds_sum did not grow")
+	}
+	// Buckets are cumulative and end with +Inf
+	bucket := regexp.MustCompile(`^yamlvalidator_validation_duration_seconds_bucket\{le="([^"]+)"\}$`)
+	var bounds []float64
+	for series := range after {
+		if m := bucket.FindStringSubmatch(series); m != nil && m[1] != "+Inf" {
+			b, _ := strconv.ParseFloat(m[1], 64)
+			bounds = append(bounds, b)
+		}
+	}
+	if len(bounds) == 0 {
+		t.Fatal("no histogram buckets")
+	}
+	for _, b := range bounds {
+		if after[`yamlvalidator_validation_duration_seconds_bucket{le="`+strconv.FormatFloat(b, 'g', -1, 64)+`"}`] > after[`yamlvalidator_validation_duration_seconds_bucket{le="+Inf"}`] {
+			t.Errorf("bucket %v holds more than +Inf", b)
+		}
+	}
+	// No series is labelled by file name
+	for series := range after {
+		if strings.Contains(series, "failing.yaml") {
+			t.Errorf("series %s is labelled by file name", series)
+		}
+	}
+
+	// A body that is not YAML counts as a parse failure
+	resp, err = http.Post(srv.URL+"/validate", "application/yaml", strings.NewReader("a: [\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := scrape(t, srv.URL)["yamlvalidator_parse_failures_total"] - after["yamlvalidator_parse_failures_total"]; got != 1 {
+		t.Errorf("yamlvalidator_parse_failures_total went up by %v, want 1", got)
+	}
+}
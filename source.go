@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSourceWidth is the number of characters of a source line shown around
+// the column of a finding.
+const maxSourceWidth = 100
+
+// formatSource renders the source line at line with a caret under column,
+// plus one line of context on each side, in the style of compiler
+// diagnostics. lines holds the file split on newlines.
+func formatSource(lines []string, line, column int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	var b strings.Builder
+	width := len(fmt.Sprint(min(line+1, len(lines))))
+	for n := max(line-1, 1); n <= min(line+1, len(lines)); n++ {
+		text, caret := clipLine([]rune(strings.TrimRight(lines[n-1], "\r")), column)
+		if n != line && strings.TrimSpace(text) == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  %*d | %s\n", width, n, text)
+		if n == line && column > 0 {
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", caret)
+		}
+	}
+	return b.String()
+}
+
+// clipLine cuts a long line down to a window around column, marking the cut
+// ends with "...", and returns it with the padding that puts a caret under
+// column. Tabs are kept in the padding so the caret lines up however wide
+// the terminal renders them.
+func clipLine(text []rune, column int) (string, string) {
+	col := column - 1
+	if col < 0 || col > len(text) {
+		col = 0
+	}
+	start, end := 0, len(text)
+	prefix, suffix := "", ""
+	if len(text) > maxSourceWidth {
+		start = max(col-maxSourceWidth/2, 0)
+		end = min(start+maxSourceWidth, len(text))
+		start = max(end-maxSourceWidth, 0)
+		if start > 0 {
+			prefix = "..."
+		}
+		if end < len(text) {
+			suffix = "..."
+		}
+	}
+	var pad strings.Builder
+	pad.WriteString(strings.Repeat(" ", len(prefix)))
+	for _, r := range text[start:col] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return prefix + string(text[start:end]) + suffix, pad.String()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultWidth is the width text is wrapped to when the terminal's is not
+// known.
+const defaultWidth = 80
+
+// terminalWidth returns the number of columns to wrap text written to f
+// at: $COLUMNS if set, else the width of the terminal behind f, else
+// defaultWidth.
+func terminalWidth(f *os.File) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if n := consoleWidth(f); n > 0 {
+		return n
+	}
+	return defaultWidth
+}
+
+// wrapText breaks text into lines of at most width columns. The first line
+// starts with first and the others with indent. Words longer than a line
+// are left whole.
+func wrapText(text, first, indent string, width int) string {
+	var b strings.Builder
+	line, empty := first, true
+	for _, word := range strings.Fields(text) {
+		if !empty && len(line)+1+len(word) > width {
+			b.WriteString(line + "\n")
+			line, empty = indent, true
+		}
+		if !empty {
+			line += " "
+		}
+		line, empty = line+word, false
+	}
+	if !empty {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
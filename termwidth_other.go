@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// consoleWidth reports that the terminal width is unknown.
+func consoleWidth(f *os.File) int {
+	return 0
+}
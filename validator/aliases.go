@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveAlias follows node.Alias until it reaches a node that is not an
+// alias. It returns nil if the chain is broken or loops back on itself.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	seen := map[*yaml.Node]bool{}
+	for node != nil && node.Kind == yaml.AliasNode {
+		if seen[node] {
+			return nil
+		}
+		seen[node] = true
+		node = node.Alias
+	}
+	return node
+}
+
+// expandAliases replaces every alias below node by a copy of the anchored
+// node, so the validators see aliased content wherever it is used. Copies
+// take the position of the alias and are recorded in copies; uses records
+// the alias name by line so findings can be annotated. Aliases that would
+// expand into themselves are left in place.
+func expandAliases(node *yaml.Node, uses map[int]string, copies, active map[*yaml.Node]bool) {
+	for i, child := range node.Content {
+		if child.Kind != yaml.AliasNode {
+			expandAliases(child, uses, copies, active)
+			continue
+		}
+		target := resolveAlias(child)
+		if target == nil || active[target] {
+			continue
+		}
+		cp := copyNodeAt(target, child.Line, child.Column)
+		uses[child.Line] = child.Value
+		copies[cp] = true
+		active[target] = true
+		expandAliases(cp, uses, copies, active)
+		delete(active, target)
+		node.Content[i] = cp
+	}
+}
+
+// copyNodeAt returns a deep copy of node with every position set to
+// line:column. Nested aliases are copied as aliases.
+func copyNodeAt(node *yaml.Node, line, column int) *yaml.Node {
+	cp := *node
+	cp.Anchor = ""
+	cp.Line, cp.Column = line, column
+	cp.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		cp.Content[i] = copyNodeAt(child, line, column)
+	}
+	return &cp
+}
+
+// annotateAliasUses appends a note to findings reported on a line where an
+// alias was expanded.
+func annotateAliasUses(findings []Finding, uses map[int]string) {
+	for i, f := range findings {
+		if name, ok := uses[f.Line]; ok {
+			findings[i].Message = fmt.Sprintf("%s (reached via alias *%s)", f.Message, name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package validator
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAliasedInvalidContent(t *testing.T) {
+	// The anchored quantity is reported where it is defined, and again at
+	// the alias for the container that uses it through the alias.
+	checkFindings(t, ofRule(validateFixture(t, "alias-invalid.yaml", nil), "cpu-quantity"), []string{
+		"14:14 error spec.containers[0].resources.limits.cpu is not a valid quantity",
+		"20:16 error spec.containers[1].resources.limits.cpu is not a valid quantity (reached via alias *res)",
+	})
+}
+
+func TestResolveAlias(t *testing.T) {
+	target := &yaml.Node{Kind: yaml.ScalarNode, Value: "500m"}
+	chain := &yaml.Node{Kind: yaml.AliasNode, Alias: &yaml.Node{Kind: yaml.AliasNode, Alias: target}}
+	if got := resolveAlias(chain); got != target {
+		t.Errorf("resolveAlias(chain) = %v, want the scalar at its end", got)
+	}
+	loop := &yaml.Node{Kind: yaml.AliasNode}
+	loop.Alias = &yaml.Node{Kind: yaml.AliasNode, Alias: loop}
+	if got := resolveAlias(loop); got != nil {
+		t.Errorf("resolveAlias(loop) = %v, want nil", got)
+	}
+	if got := resolveAlias(&yaml.Node{Kind: yaml.AliasNode}); got != nil {
+		t.Errorf("resolveAlias(broken) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// invisibleRunes are the characters that look like a space, or like
+// nothing, but are neither to a YAML parser.
+var invisibleRunes = map[rune]string{
+	'\u00a0': "non-breaking space (U+00A0), which YAML does not treat as a space",
+	'\u200b': "zero-width space (U+200B), which is invisible but part of the key or value around it",
+	'\u200c': "zero-width non-joiner (U+200C), which is invisible but part of the key or value around it",
+	'\u200d': "zero-width joiner (U+200D), which is invisible but part of the key or value around it",
+	'\u2060': "word joiner (U+2060), which is invisible but part of the key or value around it",
+	'\ufeff': "byte order mark (U+FEFF) past the start of the file, which is invisible but part of the key or value around it",
+}
+
+// blockScalarRe matches a line that starts a literal or folded block
+// scalar, such as "script: |" or "- >-".
+var blockScalarRe = regexp.MustCompile(`(^|[:\-]\s+|^\s*)[|>][-+0-9]*\s*(#.*)?$`)
+
+// scanCharacters reports the characters of a YAML stream that are easy to
+// paste in by accident and hard to see: tabs used for indentation, which
+// YAML forbids, non-breaking and zero-width spaces, and carriage returns
+// that do not end a line. It works on the raw bytes, so it reports them
+// even when the stream then fails to parse. Non-breaking spaces and
+// indenting tabs are taken as content inside block scalars. JSON input,
+// where tabs are whitespace, is not scanned.
+func scanCharacters(filename string, data []byte) []Finding {
+	if strings.EqualFold(filepath.Ext(filename), ".json") || startsObject(data) {
+		return nil
+	}
+	var findings []Finding
+	at := func(line, column int, format string, args ...any) Finding {
+		return warningAt(filename, &yaml.Node{Line: line, Column: column}, format, args...)
+	}
+	blockIndent := -1
+	for i, raw := range bytes.Split(bytes.TrimPrefix(data, utf8BOM), []byte("\n")) {
+		line := i + 1
+		text := string(bytes.TrimSuffix(raw, []byte("\r")))
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		inBlock := blockIndent >= 0 && (strings.TrimSpace(text) == "" || indent > blockIndent)
+		if !inBlock {
+			blockIndent = -1
+			if lead := text[:len(text)-len(strings.TrimLeft(text, " \t"))]; strings.Contains(lead, "\t") {
+				f := at(line, utf8.RuneCountInString(lead[:strings.Index(lead, "\t")])+1, "tab character used for indentation; YAML only allows spaces")
+				f.Severity = SeverityError
+				findings = append(findings, f)
+			}
+			if blockScalarRe.MatchString(text) {
+				blockIndent = indent
+			}
+		}
+		column := 0
+		for _, r := range text {
+			column++
+			switch {
+			case r == '\r':
+				findings = append(findings, at(line, column, "carriage return inside a line; the line is split here by some tools and not by others"))
+			case r == '\u00a0' && inBlock:
+			case invisibleRunes[r] != "":
+				findings = append(findings, at(line, column, "%s", invisibleRunes[r]))
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,252 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SeverityOff disables a rule in a Config.
+const SeverityOff Severity = "off"
+
+// defaultOSNames are the spec.os values accepted by os-value.
+var defaultOSNames = []string{"linux", "windows"}
+
+// Config adjusts the rules of a run. A nil or empty Config runs every rule
+// with its default severity and options.
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+	// ReportUnusedIgnores reports lint-ignore comments that silence nothing.
+	ReportUnusedIgnores bool `yaml:"reportUnusedIgnores"`
+	// StrictFields reports keys that are not fields of the object's type
+	// under the unknown-field rule.
+	StrictFields bool `yaml:"strictFields"`
+	// Presets lists the presets whose rules run, and Enable the rules that
+	// run although their preset is not in Presets.
+	Presets []string `yaml:"presets,omitempty"`
+	Enable  []string `yaml:"enable,omitempty"`
+	// Kinds, if set, restricts validation to the documents of these kinds,
+	// and documents of the kinds in SkipKinds are left out. Both match
+	// regardless of case.
+	Kinds     []string `yaml:"kinds,omitempty"`
+	SkipKinds []string `yaml:"skipKinds,omitempty"`
+	// TargetKubeVersion is the Kubernetes release, such as 1.29, that
+	// deprecated-api checks apiVersions against. Empty means the newest.
+	TargetKubeVersion string `yaml:"targetKubeVersion,omitempty"`
+	// MaxBytes, MaxNodes and MaxDepth bound the size of a stream, the
+	// number of nodes of a document once its aliases are expanded, and how
+	// deep a document nests. Zero means DefaultMaxBytes, DefaultMaxNodes
+	// and DefaultMaxDepth.
+	MaxBytes int64 `yaml:"maxBytes,omitempty"`
+	MaxNodes int   `yaml:"maxNodes,omitempty"`
+	MaxDepth int   `yaml:"maxDepth,omitempty"`
+	// Schema, if set, is what the schema rule checks objects against. It
+	// is loaded by the caller rather than from the configuration file.
+	Schema *Schema `yaml:"-"`
+}
+
+// RuleConfig configures a single rule.
+type RuleConfig struct {
+	// Severity is off, warning or error. Empty keeps the rule's findings as
+	// they are.
+	Severity Severity `yaml:"severity"`
+	// Allowed lists the spec.os values accepted by os-value.
+	Allowed []string `yaml:"allowed,omitempty"`
+	// AllowLatest lets image-tag accept images tagged latest.
+	AllowLatest bool `yaml:"allowLatest,omitempty"`
+	// Millicores makes cpu-quantity ask for fractional CPU amounts in
+	// millicores, 500m rather than 0.5.
+	Millicores bool `yaml:"millicores,omitempty"`
+	// Require makes resource-requirements ask only for requests or only
+	// for limits rather than both.
+	Require string `yaml:"require,omitempty"`
+	// ExemptContainers lists name patterns, as matched by path.Match, of
+	// the containers that resource-requirements, readiness-probe and
+	// identical-probes leave alone.
+	ExemptContainers []string `yaml:"exemptContainers,omitempty"`
+}
+
+// ParseConfig decodes a configuration file. Unknown fields, rules and
+// severities are errors.
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if cfg.TargetKubeVersion != "" {
+		if _, err := ParseKubeVersion(cfg.TargetKubeVersion); err != nil {
+			return nil, fmt.Errorf("targetKubeVersion: %v", err)
+		}
+	}
+	if cfg.MaxBytes < 0 || cfg.MaxNodes < 0 || cfg.MaxDepth < 0 {
+		return nil, fmt.Errorf("maxBytes, maxNodes and maxDepth must not be negative")
+	}
+	for _, p := range cfg.Presets {
+		if err := CheckPreset(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range cfg.Enable {
+		if err := CheckRuleID(id); err != nil {
+			return nil, err
+		}
+	}
+	for id, rc := range cfg.Rules {
+		if err := CheckRuleID(id); err != nil {
+			return nil, err
+		}
+		switch rc.Severity {
+		case "", SeverityOff, SeverityWarning, SeverityError:
+		default:
+			return nil, fmt.Errorf("rule %s: unknown severity %q, want off, warning or error", id, rc.Severity)
+		}
+		switch rc.Require {
+		case "", "requests", "limits":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown require %q, want requests or limits", id, rc.Require)
+		}
+		for _, pattern := range rc.ExemptContainers {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %s: exemptContainers pattern %q: %v", id, pattern, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// SetSeverity overrides the severity of the rule id.
+func (c *Config) SetSeverity(id string, s Severity) error {
+	if err := CheckRuleID(id); err != nil {
+		return err
+	}
+	if c.Rules == nil {
+		c.Rules = map[string]RuleConfig{}
+	}
+	rc := c.Rules[id]
+	rc.Severity = s
+	c.Rules[id] = rc
+	return nil
+}
+
+// Clone returns a copy of c that can be changed without affecting c.
+func (c *Config) Clone() *Config {
+	cp := &Config{Rules: map[string]RuleConfig{}}
+	if c != nil {
+		cp.ReportUnusedIgnores = c.ReportUnusedIgnores
+		cp.StrictFields = c.StrictFields
+		cp.Schema = c.Schema
+		cp.Kinds, cp.SkipKinds = c.Kinds, c.SkipKinds
+		cp.TargetKubeVersion = c.TargetKubeVersion
+		cp.MaxBytes, cp.MaxNodes, cp.MaxDepth = c.MaxBytes, c.MaxNodes, c.MaxDepth
+		cp.Presets, cp.Enable = slices.Clone(c.Presets), slices.Clone(c.Enable)
+		for id, rc := range c.Rules {
+			cp.Rules[id] = rc
+		}
+	}
+	return cp
+}
+
+// rule returns the configuration of the rule id.
+func (c *Config) rule(id string) RuleConfig {
+	if c == nil {
+		return RuleConfig{}
+	}
+	return c.Rules[id]
+}
+
+// enabled reports whether the rule r runs.
+func (c *Config) enabled(r Rule) bool {
+	rc := c.rule(r.ID)
+	if rc.Severity == SeverityOff {
+		return false
+	}
+	if r.Preset == "" || rc.Severity != "" {
+		return true
+	}
+	return c != nil && (slices.Contains(c.Presets, r.Preset) || slices.Contains(c.Enable, r.ID))
+}
+
+// EnabledRules returns the IDs of the rules that run under c, in the order
+// of Rules. Rules that wait on an option, such as StrictFields, only run
+// when it is set.
+func (c *Config) EnabledRules() []string {
+	var ids []string
+	for _, r := range rules {
+		if !c.enabled(r) {
+			continue
+		}
+		switch r.ID {
+		case "unknown-field":
+			if c == nil || !c.StrictFields {
+				continue
+			}
+		case "schema":
+			if c == nil || c.Schema == nil {
+				continue
+			}
+		case "unused-ignore":
+			if c == nil || !c.ReportUnusedIgnores {
+				continue
+			}
+		}
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// targetKubeVersion returns the parsed TargetKubeVersion, or nil if none
+// is set.
+func (c *Config) targetKubeVersion() *KubeVersion {
+	if c == nil || c.TargetKubeVersion == "" {
+		return nil
+	}
+	v, err := ParseKubeVersion(c.TargetKubeVersion)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// checksKind reports whether documents of kind are validated.
+func (c *Config) checksKind(kind string) bool {
+	if c == nil {
+		return true
+	}
+	match := func(k string) bool { return strings.EqualFold(k, kind) }
+	if len(c.Kinds) > 0 && !slices.ContainsFunc(c.Kinds, match) {
+		return false
+	}
+	return !slices.ContainsFunc(c.SkipKinds, match)
+}
+
+// ClosestRuleID returns the registered rule ID closest to id, or "" if
+// none is close enough to be a likely typo.
+func ClosestRuleID(id string) string {
+	ids := map[string]bool{}
+	for _, r := range rules {
+		ids[r.ID] = true
+	}
+	return closestName(id, ids)
+}
+
+// CheckRuleID reports an id that is not in the registry, listing the valid
+// ones.
+func CheckRuleID(id string) error {
+	var ids []string
+	for _, r := range rules {
+		if r.ID == id {
+			return nil
+		}
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("unknown rule %q; valid rules are: %s", id, strings.Join(ids, ", "))
+}
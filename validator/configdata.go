@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDataBytes is the most data a ConfigMap or Secret can hold, since etcd
+// limits the size of an object.
+const maxDataBytes = 1 << 20
+
+// dataKeyRe matches the keys allowed in the data of a ConfigMap or Secret.
+var dataKeyRe = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// secretTypes lists the Secret types Kubernetes defines.
+var secretTypes = map[string]bool{
+	"Opaque": true, "kubernetes.io/service-account-token": true, "kubernetes.io/dockercfg": true,
+	"kubernetes.io/dockerconfigjson": true, "kubernetes.io/basic-auth": true, "kubernetes.io/ssh-auth": true,
+	"kubernetes.io/tls": true, "bootstrap.kubernetes.io/token": true,
+}
+
+// validateConfigData checks the data of ConfigMaps and Secrets: the keys,
+// that Secret data and binaryData are base64, the Secret type and the
+// total size. The types of string values are left to string-value.
+func validateConfigData(d *document) []Finding {
+	var sections []string
+	switch d.kind {
+	case "ConfigMap":
+		sections = []string{"data", "binaryData"}
+	case "Secret":
+		sections = []string{"data", "stringData"}
+	default:
+		return nil
+	}
+	filename := d.filename
+	var errs []Finding
+	// seen maps each key to the section that first set it, as a key may
+	// only be in one of them; stringData overriding data is the exception.
+	seen := map[string]string{}
+	size, tooBig := 0, false
+	for _, section := range sections {
+		dataNode := findMapKey(d.mapping, section)
+		if dataNode == nil || dataNode.Tag == "!!null" {
+			continue
+		}
+		if dataNode.Kind != yaml.MappingNode {
+			errs = append(errs, errorAt(filename, dataNode, "%s must be a mapping", section))
+			continue
+		}
+		encoded := section == "binaryData" || d.kind == "Secret" && section == "data"
+		entries := mapEntries(dataNode)
+		for i := 0; i < len(entries); i += 2 {
+			keyNode, valNode := entries[i], entries[i+1]
+			key, field := keyNode.Value, joinPath(section, keyNode.Value)
+			switch {
+			case len(key) > 253:
+				errs = append(errs, errorAt(filename, keyNode, "%s key must be no more than 253 characters", section))
+			case key == "." || key == ".." || !dataKeyRe.MatchString(key):
+				errs = append(errs, errorAt(filename, keyNode, "%s key '%s' must consist of alphanumeric characters, '-', '_' or '.'", section, key))
+			}
+			if prev, ok := seen[key]; ok && !(prev == "data" && section == "stringData") {
+				errs = append(errs, errorAt(filename, keyNode, "%s duplicates the key in %s", field, prev))
+			} else if !ok {
+				seen[key] = section
+			}
+			if valNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			if !encoded {
+				size += len(valNode.Value)
+				continue
+			}
+			b, err := base64.StdEncoding.DecodeString(valNode.Value)
+			if err != nil {
+				errs = append(errs, errorAt(filename, valNode, "%s must be base64 encoded", field))
+				continue
+			}
+			size += len(b)
+		}
+		if size > maxDataBytes && !tooBig {
+			errs = append(errs, warningAt(filename, dataNode, "%s data adds up to more than 1MiB, more than an object can store", d.kind))
+			tooBig = true
+		}
+	}
+	if d.kind == "Secret" {
+		errs = append(errs, validateSecretType(d)...)
+	}
+	return errs
+}
+
+// validateSecretType checks that the type of a Secret is defined by
+// Kubernetes or prefixed by a domain, and for docker config Secrets that
+// the config is JSON with an auths key.
+func validateSecretType(d *document) []Finding {
+	typeNode := findMapKey(d.mapping, "type")
+	if typeNode == nil || typeNode.Kind != yaml.ScalarNode {
+		return nil
+	}
+	secretType := typeNode.Value
+	if !secretTypes[secretType] {
+		if s := closestName(secretType, secretTypes); s != "" {
+			return []Finding{warningAt(d.filename, typeNode, "type '%s' is not a Kubernetes Secret type (did you mean '%s'?)", secretType, s)}
+		}
+		if prefix, name, ok := strings.Cut(secretType, "/"); !ok || name == "" || !isDNS1123Subdomain(prefix) {
+			return []Finding{warningAt(d.filename, typeNode, "type '%s' is not a Kubernetes Secret type; custom types should be prefixed by a domain, as in example.com/%s", secretType, secretType)}
+		}
+		return nil
+	}
+	if secretType != "kubernetes.io/dockerconfigjson" {
+		return nil
+	}
+	const key = ".dockerconfigjson"
+	var payload []byte
+	valNode := findMapKey(findMapKey(d.mapping, "stringData"), key)
+	if valNode != nil && valNode.Kind == yaml.ScalarNode {
+		payload = []byte(valNode.Value)
+	} else if valNode = findMapKey(findMapKey(d.mapping, "data"), key); valNode != nil && valNode.Kind == yaml.ScalarNode {
+		var err error
+		if payload, err = base64.StdEncoding.DecodeString(valNode.Value); err != nil {
+			// Reported with the other data
+			return nil
+		}
+	} else {
+		return []Finding{errorAt(d.filename, typeNode, "type %s requires the key %s in data or stringData", secretType, key)}
+	}
+	field := "data"
+	if findMapKey(findMapKey(d.mapping, "stringData"), key) == valNode {
+		field = "stringData"
+	}
+	field = joinPath(field, key)
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return []Finding{errorAt(d.filename, valNode, "%s is not a JSON object: %v", field, err)}
+	}
+	if _, ok := config["auths"]; !ok {
+		return []Finding{errorAt(d.filename, valNode, "%s must have an auths key", field)}
+	}
+	return nil
+}
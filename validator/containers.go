@@ -0,0 +1,231 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// containerLists names the pod spec fields holding containers.
+var containerLists = []string{"containers", "initContainers"}
+
+// forEachContainer calls fn for every container mapping in the pod spec
+// together with its field path, e.g. spec.initContainers[0].
+func forEachContainer(specNode *yaml.Node, specPath string, fn func(contNode *yaml.Node, path string, init bool)) {
+	for _, list := range containerLists {
+		contsNode := findMapKey(specNode, list)
+		if contsNode == nil || contsNode.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, contNode := range contsNode.Content {
+			if contNode.Kind != yaml.MappingNode {
+				continue
+			}
+			fn(contNode, fmt.Sprintf("%s.%s[%d]", specPath, list, i), list == "initContainers")
+		}
+	}
+}
+
+// validateContainerNames requires every container to have a valid name that
+// is unique across containers and initContainers. Duplicates are reported at
+// the later occurrence.
+func validateContainerNames(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	type occurrence struct {
+		node *yaml.Node
+		path string
+	}
+	seen := map[string]occurrence{}
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		nameNode, missing := requireKey(filename, contNode, path, "name")
+		if nameNode == nil {
+			errs = append(errs, missing)
+			return
+		}
+		if nameNode.Kind != yaml.ScalarNode {
+			errs = append(errs, errorAt(filename, nameNode, "%s.name must be string", path))
+			return
+		}
+		name := nameNode.Value
+		if !isDNS1123Label(name) {
+			errs = append(errs, errorAt(filename, nameNode, "%s.name '%s' is not a valid DNS-1123 label", path, name))
+		}
+		first, dup := seen[name]
+		if !dup {
+			seen[name] = occurrence{nameNode, path}
+			return
+		}
+		// containers are walked before initContainers, so report whichever
+		// occurrence comes later in the file
+		later := occurrence{nameNode, path}
+		if first.node.Line > later.node.Line {
+			later = first
+		}
+		errs = append(errs, errorAt(filename, later.node, "%s.name duplicates container name '%s'", later.path, name))
+	})
+	return errs
+}
+
+// splitImageRef splits an image reference into its tag and digest. A colon
+// before the last '/' belongs to the registry host (registry.local:5000/app)
+// and is not a tag separator.
+func splitImageRef(ref string) (tag, digest string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref, digest = ref[:i], ref[i+1:]
+	}
+	last := ref[strings.LastIndex(ref, "/")+1:]
+	if i := strings.LastIndex(last, ":"); i >= 0 {
+		tag = last[i+1:]
+	}
+	return tag, digest
+}
+
+func validateImage(contNode *yaml.Node, path string, allowLatest bool, filename string) []Finding {
+	imageNode, missing := requireKey(filename, contNode, path, "image")
+	if imageNode == nil {
+		return []Finding{missing}
+	}
+	if imageNode.Kind != yaml.ScalarNode {
+		return []Finding{errorAt(filename, imageNode, "%s.image must be string", path)}
+	}
+	// An empty image can never be pulled, so it is as wrong as a missing one
+	if imageNode.Value == "" {
+		return []Finding{errorAt(filename, imageNode, "%s.image must not be empty", path)}
+	}
+	tag, digest := splitImageRef(imageNode.Value)
+	switch {
+	case digest != "":
+		return nil
+	case tag == "":
+		return []Finding{warningAt(filename, imageNode, "%s.image '%s' has no tag or digest", path, imageNode.Value)}
+	case tag == "latest" && !allowLatest:
+		return []Finding{warningAt(filename, imageNode, "%s.image '%s' uses the latest tag", path, imageNode.Value)}
+	}
+	return nil
+}
+
+// validateContainerRestartPolicy checks restartPolicy on a single container.
+// It is only supported on init containers, where Always marks the container
+// as a sidecar.
+func validateContainerRestartPolicy(contNode *yaml.Node, path string, init bool, filename string) []Finding {
+	policyNode := findMapKey(contNode, "restartPolicy")
+	if policyNode == nil {
+		return nil
+	}
+	if !init {
+		return []Finding{errorAt(filename, policyNode, "%s.restartPolicy is not supported on regular containers; set it on the pod spec or move the container to initContainers to make it a sidecar", path)}
+	}
+	if policyNode.Value != "Always" {
+		return []Finding{errorAt(filename, policyNode, "%s.restartPolicy has unsupported value '%s'; only Always is allowed, which makes the init container a sidecar", path, policyNode.Value)}
+	}
+	return nil
+}
+
+// validateCommandArgs requires command and args to be lists of strings. A
+// single string is a common mistake, so the message suggests list syntax.
+func validateCommandArgs(contNode *yaml.Node, path, filename string) []Finding {
+	var errs []Finding
+	for _, key := range []string{"command", "args"} {
+		valNode := findMapKey(contNode, key)
+		if valNode == nil {
+			continue
+		}
+		switch valNode.Kind {
+		case yaml.SequenceNode:
+			for _, item := range valNode.Content {
+				if item.Kind != yaml.ScalarNode {
+					errs = append(errs, errorAt(filename, item, "%s.%s items must be strings", path, key))
+				}
+			}
+		case yaml.ScalarNode:
+			snippet := valNode.Value
+			if r := []rune(snippet); len(r) > 40 {
+				snippet = string(r[:40]) + "..."
+			}
+			errs = append(errs, errorAt(filename, valNode, "%s.%s must be a list, not the string '%s'; use list syntax such as [\"/bin/sh\", \"-c\", \"...\"]", path, key, snippet))
+		default:
+			errs = append(errs, errorAt(filename, valNode, "%s.%s must be a list", path, key))
+		}
+	}
+	return errs
+}
+
+// validateWorkingDir requires workingDir to be an absolute path on Linux
+// pods. Pods without spec.os are assumed to run Linux.
+func validateWorkingDir(contNode *yaml.Node, path, osName, filename string) []Finding {
+	dirNode := findMapKey(contNode, "workingDir")
+	if dirNode == nil || osName == "windows" {
+		return nil
+	}
+	if dirNode.Kind != yaml.ScalarNode || !strings.HasPrefix(dirNode.Value, "/") {
+		return []Finding{errorAt(filename, dirNode, "%s.workingDir must be an absolute path", path)}
+	}
+	return nil
+}
+
+var imagePullPolicies = []string{"Always", "IfNotPresent", "Never"}
+
+func validateImagePullPolicy(contNode *yaml.Node, path, filename string) []Finding {
+	policyNode := findMapKey(contNode, "imagePullPolicy")
+	if policyNode == nil {
+		return nil
+	}
+	return validateEnum(policyNode, path+".imagePullPolicy", imagePullPolicies, filename)
+}
+
+var portProtocols = []string{"TCP", "UDP", "SCTP"}
+
+// validatePortNumber checks that portNode holds an integer in 1-65535.
+func validatePortNumber(portNode *yaml.Node, field, filename string) []Finding {
+	portVal, err := strconv.Atoi(portNode.Value)
+	if portNode.Kind != yaml.ScalarNode || err != nil || portVal < 1 || portVal > 65535 {
+		return []Finding{errorAt(filename, portNode, "%s value out of range", field)}
+	}
+	return nil
+}
+
+// validatePorts checks the ports of every container. containerPort/protocol
+// pairs and port names must be unique across the whole pod.
+func validatePorts(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	seenPorts := map[string]bool{}
+	seenNames := map[string]bool{}
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		portsNode := findMapKey(contNode, "ports")
+		if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, portNode := range portsNode.Content {
+			if portNode.Kind != yaml.MappingNode {
+				continue
+			}
+			field := fmt.Sprintf("%s.ports[%d]", path, i)
+			protocol := "TCP"
+			if protoNode := findMapKey(portNode, "protocol"); protoNode != nil {
+				errs = append(errs, validateEnum(protoNode, field+".protocol", portProtocols, filename)...)
+				protocol = protoNode.Value
+			}
+			if hostNode := findMapKey(portNode, "hostPort"); hostNode != nil {
+				errs = append(errs, validatePortNumber(hostNode, field+".hostPort", filename)...)
+			}
+			if cpNode := findMapKey(portNode, "containerPort"); cpNode != nil {
+				portErrs := validatePortNumber(cpNode, field+".containerPort", filename)
+				errs = append(errs, portErrs...)
+				key := cpNode.Value + "/" + protocol
+				if len(portErrs) == 0 && seenPorts[key] {
+					errs = append(errs, errorAt(filename, cpNode, "%s.containerPort duplicates port %s/%s", field, cpNode.Value, protocol))
+				}
+				seenPorts[key] = true
+			}
+			if nameNode := findMapKey(portNode, "name"); nameNode != nil && nameNode.Kind == yaml.ScalarNode {
+				if seenNames[nameNode.Value] {
+					errs = append(errs, errorAt(filename, nameNode, "%s.name duplicates port name '%s'", field, nameNode.Value))
+				}
+				seenNames[nameNode.Value] = true
+			}
+		}
+	})
+	return errs
+}
@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestImagePullPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{"Always", nil},
+		{"IfNotPresent", nil},
+		{"Never", nil},
+		{"always", []string{"8:22 error spec.containers[0].imagePullPolicy has unsupported value 'always' (did you mean 'Always'?)"}},
+		{"IFNOTPRESENT", []string{"8:22 error spec.containers[0].imagePullPolicy has unsupported value 'IFNOTPRESENT' (did you mean 'IfNotPresent'?)"}},
+		{"Sometimes", []string{"8:22 error spec.containers[0].imagePullPolicy has unsupported value 'Sometimes'"}},
+		{"[Always]", []string{"8:22 error spec.containers[0].imagePullPolicy must be string"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - image: nginx:1.27
+    imagePullPolicy: %s
+    name: web
+`, tt.policy), nil)
+			checkFindings(t, ofRule(findings, "image-pull-policy"), tt.want)
+		})
+	}
+}
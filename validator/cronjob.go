@@ -0,0 +1,116 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var concurrencyPolicies = []string{"Allow", "Forbid", "Replace"}
+
+// cronMacros lists the predefined schedules accepted in place of five fields.
+var cronMacros = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// cronFields describes the five fields of a cron expression in order.
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string
+}{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{"day of week", 0, 7, []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// checkCronSchedule returns a description of what is wrong with a cron
+// schedule, or "" if it is valid.
+func checkCronSchedule(schedule string) string {
+	schedule = strings.TrimSpace(schedule)
+	if strings.HasPrefix(schedule, "@") {
+		if cronMacros[schedule] {
+			return ""
+		}
+		return fmt.Sprintf("unknown macro '%s'", schedule)
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return fmt.Sprintf("must have 5 fields (minute hour day-of-month month day-of-week), found %d", len(fields))
+	}
+	for i, f := range fields {
+		spec := cronFields[i]
+		for _, item := range strings.Split(f, ",") {
+			if !validCronItem(item, spec.min, spec.max, spec.names) {
+				return fmt.Sprintf("%s field '%s' is invalid", spec.name, f)
+			}
+		}
+	}
+	return ""
+}
+
+// validCronItem checks one comma-separated item of a cron field: *, ?, a
+// value or a range, optionally followed by /step.
+func validCronItem(item string, min, max int, names []string) bool {
+	rangePart, step, hasStep := strings.Cut(item, "/")
+	if hasStep {
+		if n, err := strconv.Atoi(step); err != nil || n < 1 {
+			return false
+		}
+	}
+	if rangePart == "*" || rangePart == "?" {
+		return true
+	}
+	lo, hi, isRange := strings.Cut(rangePart, "-")
+	loVal, ok := cronValue(lo, min, max, names)
+	if !ok {
+		return false
+	}
+	if !isRange {
+		return true
+	}
+	hiVal, ok := cronValue(hi, min, max, names)
+	return ok && loVal <= hiVal
+}
+
+func cronValue(s string, min, max int, names []string) (int, bool) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i + min, true
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= min && n <= max
+}
+
+// validateCronJob checks the CronJob fields surrounding its job template.
+func validateCronJob(d *document) []Finding {
+	if d.kind != "CronJob" {
+		return nil
+	}
+	filename := d.filename
+	specNode := findMapKey(d.mapping, "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	if schedNode, missing := requireKey(filename, specNode, "spec", "schedule"); schedNode == nil {
+		errs = append(errs, missing)
+	} else if problem := checkCronSchedule(schedNode.Value); problem != "" {
+		errs = append(errs, errorAt(filename, schedNode, "spec.schedule '%s' %s", schedNode.Value, problem))
+	}
+	if policyNode := findMapKey(specNode, "concurrencyPolicy"); policyNode != nil {
+		errs = append(errs, validateEnum(policyNode, "spec.concurrencyPolicy", concurrencyPolicies, filename)...)
+	}
+	for _, field := range []string{"startingDeadlineSeconds", "successfulJobsHistoryLimit", "failedJobsHistoryLimit"} {
+		if valNode := findMapKey(specNode, field); valNode != nil {
+			errs = append(errs, validateMinInt(valNode, "spec."+field, 0, filename)...)
+		}
+	}
+	return errs
+}
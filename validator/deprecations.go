@@ -0,0 +1,106 @@
+package validator
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed deprecations.yaml
+var deprecationsYAML []byte
+
+// deprecation is an entry of deprecations.yaml.
+type deprecation struct {
+	APIVersion   string   `yaml:"apiVersion"`
+	Kinds        []string `yaml:"kinds"`
+	DeprecatedIn string   `yaml:"deprecatedIn"`
+	RemovedIn    string   `yaml:"removedIn"`
+	Replacement  string   `yaml:"replacement"`
+}
+
+// deprecations maps apiVersion/kind, as in apps/v1beta1/Deployment, to the
+// deprecation of that kind in that apiVersion.
+var deprecations = loadDeprecations()
+
+func loadDeprecations() map[string]deprecation {
+	var entries []deprecation
+	if err := yaml.Unmarshal(deprecationsYAML, &entries); err != nil {
+		panic(fmt.Sprintf("deprecations.yaml: %v", err))
+	}
+	byKind := map[string]deprecation{}
+	for _, e := range entries {
+		for _, v := range []string{e.DeprecatedIn, e.RemovedIn} {
+			if _, err := ParseKubeVersion(v); err != nil {
+				panic(fmt.Sprintf("deprecations.yaml: %s: %v", e.APIVersion, err))
+			}
+		}
+		for _, kind := range e.Kinds {
+			byKind[e.APIVersion+"/"+kind] = e
+		}
+	}
+	return byKind
+}
+
+// KubeVersion is a Kubernetes minor release, such as 1.29.
+type KubeVersion struct {
+	Major, Minor int
+}
+
+// ParseKubeVersion parses a version written as 1.29 or v1.29. A patch
+// number, as in 1.29.3, is ignored.
+func ParseKubeVersion(s string) (KubeVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return KubeVersion{}, fmt.Errorf("invalid Kubernetes version %q, want a version like 1.29", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return KubeVersion{}, fmt.Errorf("invalid Kubernetes version %q, want a version like 1.29", s)
+		}
+		nums[i] = n
+	}
+	return KubeVersion{nums[0], nums[1]}, nil
+}
+
+// atLeast reports whether v is the release o or a later one.
+func (v KubeVersion) atLeast(o KubeVersion) bool {
+	return v.Major > o.Major || v.Major == o.Major && v.Minor >= o.Minor
+}
+
+func (v KubeVersion) String() string {
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+// validateDeprecatedAPI reports objects whose apiVersion no longer serves
+// their kind in the target Kubernetes version, as errors, and those whose
+// apiVersion is deprecated there, as warnings. Without a target version
+// every removal counts.
+func validateDeprecatedAPI(d *document) []Finding {
+	apiVersionNode := findMapKey(d.mapping, "apiVersion")
+	if apiVersionNode == nil || apiVersionNode.Kind != yaml.ScalarNode {
+		return nil
+	}
+	dep, ok := deprecations[apiVersionNode.Value+"/"+d.kind]
+	if !ok {
+		return nil
+	}
+	deprecatedIn, _ := ParseKubeVersion(dep.DeprecatedIn)
+	removedIn, _ := ParseKubeVersion(dep.RemovedIn)
+	use := ""
+	if dep.Replacement != "" {
+		use = ", use " + dep.Replacement
+	}
+	target := d.cfg.targetKubeVersion()
+	switch {
+	case target == nil || target.atLeast(removedIn):
+		return []Finding{errorAt(d.filename, apiVersionNode, "%s %s was removed in %s%s", dep.APIVersion, d.kind, removedIn, use)}
+	case target.atLeast(deprecatedIn):
+		return []Finding{warningAt(d.filename, apiVersionNode, "%s %s is deprecated since %s and removed in %s%s", dep.APIVersion, d.kind, deprecatedIn, removedIn, use)}
+	}
+	return nil
+}
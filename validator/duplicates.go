@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// validateDuplicateKeys walks the whole document and reports mapping keys
+// that appear more than once. Aliases are not followed since the anchored
+// node is already visited where it is defined, and for the same reason the
+// copies made by expanding aliases are skipped.
+func validateDuplicateKeys(node *yaml.Node, copies map[*yaml.Node]bool, filename string) []Finding {
+	if node == nil || node.Kind == yaml.AliasNode || copies[node] {
+		return nil
+	}
+	var errs []Finding
+	if node.Kind == yaml.MappingNode {
+		first := map[string]int{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			if k.Kind != yaml.ScalarNode {
+				continue
+			}
+			if line, ok := first[k.Value]; ok {
+				errs = append(errs, errorAt(filename, k, "duplicate key '%s' (first defined at line %d)", k.Value, line))
+			} else {
+				first[k.Value] = k.Line
+			}
+		}
+	}
+	for _, child := range node.Content {
+		errs = append(errs, validateDuplicateKeys(child, copies, filename)...)
+	}
+	return errs
+}
+
+// validateDuplicateObjects reports an object with the kind, namespace and
+// name of one earlier in the run, which makes applying them flap between
+// the two. Objects named through generateName get a new name each time and
+// are left alone.
+func validateDuplicateObjects(obj *Object, objs *objectIndex) []Finding {
+	if obj.Name == "" {
+		return nil
+	}
+	first := objs.find(obj.Kind, obj.Namespace, obj.Name)
+	if first == obj {
+		return nil
+	}
+	nameNode := findMapKey(findMapKey(obj.d.mapping, "metadata"), "name")
+	firstNode := findMapKey(findMapKey(first.d.mapping, "metadata"), "name")
+	return []Finding{errorAt(obj.File, nameNode, "metadata.name duplicates %s %s/%s, defined at %s:%d", obj.Kind, obj.Namespace, obj.Name, first.File, firstNode.Line)}
+}
@@ -0,0 +1,15 @@
+package validator
+
+import "testing"
+
+func TestDuplicateKeys(t *testing.T) {
+	// The finding is at the repeated key and its message names the line of
+	// the first one, so each finding gives both lines. The alias of the
+	// sidecar repeats nothing.
+	checkFindings(t, ofRule(validateFixture(t, "duplicate-keys.yaml", nil), "duplicate-key"), []string{
+		"8:5 error duplicate key 'app' (first defined at line 6)",
+		"19:9 error duplicate key 'cpu' (first defined at line 18)",
+		"23:5 error duplicate key 'resources' (first defined at line 13)",
+		"32:1 error duplicate key 'kind' (first defined at line 2)",
+	})
+}
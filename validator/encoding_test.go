@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEmptyDocuments(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"empty.yaml", []string{"1:1 warning document is empty"}},
+		{"null.yaml", []string{"1:1 warning document is empty"}},
+		{"separators.yaml", []string{"2:1 warning document is empty", "3:1 warning document is empty"}},
+		{"scalar-root.yaml", []string{"1:1 error document must be a mapping"}},
+		{"sequence-root.yaml", []string{"1:1 error document must be a mapping"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			checkFindings(t, validateFixture(t, tt.file, nil), tt.want)
+		})
+	}
+}
+
+func TestLineEndingsAndBOM(t *testing.T) {
+	want := brief(validateFixture(t, "lf.yaml", nil))
+	if len(want) == 0 {
+		t.Fatal("lf.yaml has no findings to compare")
+	}
+	for _, file := range []string{"crlf.yaml", "bom.yaml", "bom-crlf.yaml"} {
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Make sure a checkout did not convert the fixtures
+		if crlf := bytes.Contains(data, []byte("\r\n")); crlf != (file != "bom.yaml") {
+			t.Fatalf("%s: holds CRLF line endings = %v", file, crlf)
+		}
+		if bom := bytes.HasPrefix(data, utf8BOM); bom != (file != "crlf.yaml") {
+			t.Fatalf("%s: starts with a BOM = %v", file, bom)
+		}
+		if got := brief(validateFixture(t, file, nil)); !slices.Equal(got, want) {
+			t.Errorf("%s: findings %q, want those of lf.yaml %q", file, got, want)
+		}
+	}
+}
+
+// TestNonMappingRoots runs documents whose root is not a mapping through
+// everything that walks documents, none of which may panic.
+func TestNonMappingRoots(t *testing.T) {
+	for _, src := range []string{
+		"hello\n",
+		"- a\n- b\n",
+		"- {kind: Pod}\n",
+		"&a [*a]\n",
+		"42\n---\n[]\n---\n{}\n",
+		"--- |\n  text\n",
+		"~\n",
+		"[1, 2]",
+		"\"a\"",
+	} {
+		if _, err := ValidateWithConfig("test.yaml", []byte(src), &Config{Presets: Presets(), StrictFields: true}); err != nil {
+			t.Errorf("ValidateWithConfig(%q): %v", src, err)
+		}
+		Fix("test.yaml", []byte(src), nil)
+		Format("test.yaml", []byte(src))
+		Query("test.yaml", []byte(src), "spec.containers[0]")
+		Diff("a.yaml", []byte(src), "b.yaml", []byte("kind: Pod\n"), nil)
+	}
+}
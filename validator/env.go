@@ -0,0 +1,124 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateEnv checks a container's env list. Names that are not C
+// identifiers are accepted by Kubernetes but break many programs, so they
+// are only reported as warnings.
+func validateEnv(contNode *yaml.Node, path, filename string) []Finding {
+	envNode := findMapKey(contNode, "env")
+	if envNode == nil || envNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	seen := map[string]bool{}
+	for i, entry := range envNode.Content {
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.env[%d]", path, i)
+		nameNode, missing := requireKey(filename, entry, field, "name")
+		if nameNode == nil {
+			errs = append(errs, missing)
+		} else if nameNode.Kind != yaml.ScalarNode {
+			errs = append(errs, errorAt(filename, nameNode, "%s.name must be string", field))
+		} else {
+			name := nameNode.Value
+			if !isCIdentifier(name) {
+				errs = append(errs, warningAt(filename, nameNode, "%s.name '%s' is not a valid C identifier", field, name))
+			}
+			if seen[name] {
+				errs = append(errs, errorAt(filename, nameNode, "%s.name duplicates env name '%s'", field, name))
+			}
+			seen[name] = true
+		}
+
+		valueNode := findMapKey(entry, "value")
+		valueFromNode := findMapKey(entry, "valueFrom")
+		if valueNode != nil && valueFromNode != nil {
+			errs = append(errs, errorAt(filename, valueFromNode, "%s must not set both value and valueFrom", field))
+		} else if valueNode == nil && valueFromNode == nil {
+			errs = append(errs, errorAt(filename, entry, "%s must set value or valueFrom", field))
+		}
+		if valueFromNode != nil && valueFromNode.Kind == yaml.MappingNode {
+			errs = append(errs, validateValueFrom(valueFromNode, field+".valueFrom", filename)...)
+		}
+	}
+	return errs
+}
+
+// fieldRefPaths lists the downward API fields usable in env valueFrom.fieldRef.
+var fieldRefPaths = map[string]bool{
+	"metadata.name":           true,
+	"metadata.namespace":      true,
+	"metadata.uid":            true,
+	"spec.nodeName":           true,
+	"spec.serviceAccountName": true,
+	"status.hostIP":           true,
+	"status.podIP":            true,
+}
+
+var fieldRefSubscriptRe = regexp.MustCompile(`^metadata\.(labels|annotations)\['[^']+'\]$`)
+
+func validateValueFrom(valueFromNode *yaml.Node, path, filename string) []Finding {
+	var errs []Finding
+	if fieldRef := findMapKey(valueFromNode, "fieldRef"); fieldRef != nil && fieldRef.Kind == yaml.MappingNode {
+		if pathNode, missing := requireKey(filename, fieldRef, path+".fieldRef", "fieldPath"); pathNode == nil {
+			errs = append(errs, missing)
+		} else if !fieldRefPaths[pathNode.Value] && !fieldRefSubscriptRe.MatchString(pathNode.Value) {
+			errs = append(errs, errorAt(filename, pathNode, "%s.fieldRef.fieldPath has unsupported value '%s'", path, pathNode.Value))
+		}
+	}
+	for _, ref := range []string{"configMapKeyRef", "secretKeyRef"} {
+		refNode := findMapKey(valueFromNode, ref)
+		if refNode == nil || refNode.Kind != yaml.MappingNode {
+			continue
+		}
+		field := path + "." + ref
+		errs = append(errs, validateRefName(refNode, field, filename)...)
+		if keyNode, missing := requireKey(filename, refNode, field, "key"); keyNode == nil {
+			errs = append(errs, missing)
+		}
+	}
+	return errs
+}
+
+// validateRefName requires refNode.name to be a valid object name.
+func validateRefName(refNode *yaml.Node, field, filename string) []Finding {
+	nameNode, missing := requireKey(filename, refNode, field, "name")
+	if nameNode == nil {
+		return []Finding{missing}
+	}
+	if !isDNS1123Subdomain(nameNode.Value) {
+		return []Finding{errorAt(filename, nameNode, "%s.name '%s' is not a valid DNS-1123 subdomain", field, nameNode.Value)}
+	}
+	return nil
+}
+
+func validateEnvFrom(contNode *yaml.Node, path, filename string) []Finding {
+	envFromNode := findMapKey(contNode, "envFrom")
+	if envFromNode == nil || envFromNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	for i, entry := range envFromNode.Content {
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.envFrom[%d]", path, i)
+		for _, ref := range []string{"configMapRef", "secretRef"} {
+			if refNode := findMapKey(entry, ref); refNode != nil && refNode.Kind == yaml.MappingNode {
+				errs = append(errs, validateRefName(refNode, field+"."+ref, filename)...)
+			}
+		}
+		if prefixNode := findMapKey(entry, "prefix"); prefixNode != nil && !isCIdentifier(prefixNode.Value) {
+			errs = append(errs, errorAt(filename, prefixNode, "%s.prefix '%s' is not a valid C identifier prefix", field, prefixNode.Value))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,296 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldTables lists the known fields of the API types that unknown-field
+// checks, keyed by type name. Each field maps to the type of its value: a
+// type in the table for a mapping, "[]" and a type for a list of them, and
+// "" for scalars, free-form maps such as labels, and types whose fields are
+// not checked. Checking a new type only takes an entry here.
+var fieldTables = map[string]map[string]string{
+	"ObjectMeta": {
+		"name": "", "generateName": "", "namespace": "", "selfLink": "", "uid": "",
+		"resourceVersion": "", "generation": "", "creationTimestamp": "", "deletionTimestamp": "",
+		"deletionGracePeriodSeconds": "", "labels": "", "annotations": "", "ownerReferences": "",
+		"finalizers": "", "managedFields": "",
+	},
+	"LabelSelector": {
+		"matchLabels": "", "matchExpressions": "[]LabelSelectorRequirement",
+	},
+	"LabelSelectorRequirement": {
+		"key": "", "operator": "", "values": "",
+	},
+
+	"Pod": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "PodSpec", "status": "",
+	},
+	"Deployment": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "DeploymentSpec", "status": "",
+	},
+	"DeploymentSpec": {
+		"replicas": "", "selector": "LabelSelector", "template": "PodTemplateSpec", "strategy": "",
+		"minReadySeconds": "", "revisionHistoryLimit": "", "paused": "", "progressDeadlineSeconds": "",
+	},
+	"StatefulSet": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "StatefulSetSpec", "status": "",
+	},
+	"StatefulSetSpec": {
+		"replicas": "", "selector": "LabelSelector", "template": "PodTemplateSpec", "volumeClaimTemplates": "",
+		"serviceName": "", "podManagementPolicy": "", "updateStrategy": "", "revisionHistoryLimit": "",
+		"minReadySeconds": "", "persistentVolumeClaimRetentionPolicy": "", "ordinals": "",
+	},
+	"DaemonSet": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "DaemonSetSpec", "status": "",
+	},
+	"DaemonSetSpec": {
+		"selector": "LabelSelector", "template": "PodTemplateSpec", "updateStrategy": "",
+		"minReadySeconds": "", "revisionHistoryLimit": "",
+	},
+	"ReplicaSet": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "ReplicaSetSpec", "status": "",
+	},
+	"ReplicaSetSpec": {
+		"replicas": "", "minReadySeconds": "", "selector": "LabelSelector", "template": "PodTemplateSpec",
+	},
+	"Job": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "JobSpec", "status": "",
+	},
+	"JobSpec": {
+		"parallelism": "", "completions": "", "activeDeadlineSeconds": "", "podFailurePolicy": "",
+		"successPolicy": "", "backoffLimit": "", "backoffLimitPerIndex": "", "maxFailedIndexes": "",
+		"selector": "LabelSelector", "manualSelector": "", "template": "PodTemplateSpec",
+		"ttlSecondsAfterFinished": "", "completionMode": "", "suspend": "", "podReplacementPolicy": "",
+		"managedBy": "",
+	},
+	"CronJob": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "CronJobSpec", "status": "",
+	},
+	"CronJobSpec": {
+		"schedule": "", "timeZone": "", "startingDeadlineSeconds": "", "concurrencyPolicy": "",
+		"suspend": "", "jobTemplate": "JobTemplateSpec", "successfulJobsHistoryLimit": "",
+		"failedJobsHistoryLimit": "",
+	},
+	"JobTemplateSpec": {
+		"metadata": "ObjectMeta", "spec": "JobSpec",
+	},
+	"PodTemplateSpec": {
+		"metadata": "ObjectMeta", "spec": "PodSpec",
+	},
+
+	"PodSpec": {
+		"volumes": "[]Volume", "initContainers": "[]Container", "containers": "[]Container",
+		"ephemeralContainers": "", "restartPolicy": "", "terminationGracePeriodSeconds": "",
+		"activeDeadlineSeconds": "", "dnsPolicy": "", "nodeSelector": "", "serviceAccountName": "",
+		"serviceAccount": "", "automountServiceAccountToken": "", "nodeName": "", "hostNetwork": "",
+		"hostPID": "", "hostIPC": "", "shareProcessNamespace": "", "securityContext": "PodSecurityContext",
+		"imagePullSecrets": "", "hostname": "", "subdomain": "", "affinity": "Affinity",
+		"schedulerName": "", "tolerations": "[]Toleration", "hostAliases": "[]HostAlias",
+		"priorityClassName": "", "priority": "", "dnsConfig": "", "readinessGates": "",
+		"runtimeClassName": "", "enableServiceLinks": "", "preemptionPolicy": "", "overhead": "",
+		"topologySpreadConstraints": "[]TopologySpreadConstraint", "setHostnameAsFQDN": "", "os": "PodOS",
+		"hostUsers": "", "schedulingGates": "", "resourceClaims": "", "resources": "ResourceRequirements",
+	},
+	"PodSecurityContext": {
+		"seLinuxOptions": "", "windowsOptions": "", "runAsUser": "", "runAsGroup": "", "runAsNonRoot": "",
+		"supplementalGroups": "", "supplementalGroupsPolicy": "", "fsGroup": "", "sysctls": "",
+		"fsGroupChangePolicy": "", "seccompProfile": "", "appArmorProfile": "", "seLinuxChangePolicy": "",
+	},
+	"PodOS": {
+		"name": "",
+	},
+	"Affinity": {
+		"nodeAffinity": "NodeAffinity", "podAffinity": "", "podAntiAffinity": "",
+	},
+	"NodeAffinity": {
+		"requiredDuringSchedulingIgnoredDuringExecution":  "NodeSelector",
+		"preferredDuringSchedulingIgnoredDuringExecution": "[]PreferredSchedulingTerm",
+	},
+	"NodeSelector": {
+		"nodeSelectorTerms": "[]NodeSelectorTerm",
+	},
+	"NodeSelectorTerm": {
+		"matchExpressions": "[]NodeSelectorRequirement", "matchFields": "[]NodeSelectorRequirement",
+	},
+	"NodeSelectorRequirement": {
+		"key": "", "operator": "", "values": "",
+	},
+	"PreferredSchedulingTerm": {
+		"weight": "", "preference": "NodeSelectorTerm",
+	},
+	"Toleration": {
+		"key": "", "operator": "", "value": "", "effect": "", "tolerationSeconds": "",
+	},
+	"HostAlias": {
+		"ip": "", "hostnames": "",
+	},
+	"TopologySpreadConstraint": {
+		"maxSkew": "", "topologyKey": "", "whenUnsatisfiable": "", "labelSelector": "LabelSelector",
+		"minDomains": "", "nodeAffinityPolicy": "", "nodeTaintsPolicy": "", "matchLabelKeys": "",
+	},
+	"Volume": {
+		"name": "", "hostPath": "", "emptyDir": "", "gcePersistentDisk": "", "awsElasticBlockStore": "",
+		"gitRepo": "", "secret": "", "nfs": "", "iscsi": "", "glusterfs": "", "persistentVolumeClaim": "",
+		"rbd": "", "flexVolume": "", "cinder": "", "cephfs": "", "flocker": "", "downwardAPI": "",
+		"fc": "", "azureFile": "", "configMap": "", "vsphereVolume": "", "quobyte": "", "azureDisk": "",
+		"photonPersistentDisk": "", "projected": "", "portworxVolume": "", "scaleIO": "", "storageos": "",
+		"csi": "", "ephemeral": "", "image": "",
+	},
+
+	"Container": {
+		"name": "", "image": "", "command": "", "args": "", "workingDir": "", "ports": "[]ContainerPort",
+		"envFrom": "[]EnvFromSource", "env": "[]EnvVar", "resources": "ResourceRequirements",
+		"resizePolicy": "", "restartPolicy": "", "volumeMounts": "[]VolumeMount", "volumeDevices": "",
+		"livenessProbe": "Probe", "readinessProbe": "Probe", "startupProbe": "Probe",
+		"lifecycle": "Lifecycle", "terminationMessagePath": "", "terminationMessagePolicy": "",
+		"imagePullPolicy": "", "securityContext": "SecurityContext", "stdin": "", "stdinOnce": "", "tty": "",
+	},
+	"ContainerPort": {
+		"name": "", "hostPort": "", "containerPort": "", "protocol": "", "hostIP": "",
+	},
+	"EnvVar": {
+		"name": "", "value": "", "valueFrom": "EnvVarSource",
+	},
+	"EnvVarSource": {
+		"fieldRef": "", "resourceFieldRef": "", "configMapKeyRef": "", "secretKeyRef": "",
+	},
+	"EnvFromSource": {
+		"prefix": "", "configMapRef": "", "secretRef": "",
+	},
+	"ResourceRequirements": {
+		"limits": "", "requests": "", "claims": "",
+	},
+	"VolumeMount": {
+		"name": "", "readOnly": "", "recursiveReadOnly": "", "mountPath": "", "subPath": "",
+		"mountPropagation": "", "subPathExpr": "",
+	},
+	"Probe": {
+		"exec": "", "httpGet": "HTTPGetAction", "tcpSocket": "", "grpc": "", "initialDelaySeconds": "",
+		"timeoutSeconds": "", "periodSeconds": "", "successThreshold": "", "failureThreshold": "",
+		"terminationGracePeriodSeconds": "",
+	},
+	"HTTPGetAction": {
+		"path": "", "port": "", "host": "", "scheme": "", "httpHeaders": "",
+	},
+	"Lifecycle": {
+		"postStart": "LifecycleHandler", "preStop": "LifecycleHandler", "stopSignal": "",
+	},
+	"LifecycleHandler": {
+		"exec": "", "httpGet": "HTTPGetAction", "tcpSocket": "", "sleep": "",
+	},
+	"SecurityContext": {
+		"capabilities": "", "privileged": "", "seLinuxOptions": "", "windowsOptions": "", "runAsUser": "",
+		"runAsGroup": "", "runAsNonRoot": "", "readOnlyRootFilesystem": "", "allowPrivilegeEscalation": "",
+		"procMount": "", "seccompProfile": "", "appArmorProfile": "",
+	},
+
+	"Service": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "spec": "ServiceSpec", "status": "",
+	},
+	"ServiceSpec": {
+		"ports": "[]ServicePort", "selector": "", "clusterIP": "", "clusterIPs": "", "type": "",
+		"externalIPs": "", "sessionAffinity": "", "loadBalancerIP": "", "loadBalancerSourceRanges": "",
+		"externalName": "", "externalTrafficPolicy": "", "healthCheckNodePort": "",
+		"publishNotReadyAddresses": "", "sessionAffinityConfig": "", "ipFamilies": "", "ipFamilyPolicy": "",
+		"allocateLoadBalancerNodePorts": "", "loadBalancerClass": "", "internalTrafficPolicy": "",
+		"trafficDistribution": "",
+	},
+	"ServicePort": {
+		"name": "", "protocol": "", "appProtocol": "", "port": "", "targetPort": "", "nodePort": "",
+	},
+	"ConfigMap": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "data": "", "binaryData": "", "immutable": "",
+	},
+	"Secret": {
+		"apiVersion": "", "kind": "", "metadata": "ObjectMeta", "data": "", "stringData": "", "type": "",
+		"immutable": "",
+	},
+}
+
+// validateUnknownFields reports the keys of an object that are not fields
+// of its type, when the kind has an entry in fieldTables.
+func validateUnknownFields(d *document) []Finding {
+	if d.cfg == nil || !d.cfg.StrictFields {
+		return nil
+	}
+	if _, ok := fieldTables[d.kind]; !ok {
+		return nil
+	}
+	return checkFields(d.mapping, d.kind, "", d.filename)
+}
+
+// checkFields checks the keys of node against the fields of typeName and
+// descends into the fields whose types are in the table.
+func checkFields(node *yaml.Node, typeName, path, filename string) []Finding {
+	if itemType, ok := strings.CutPrefix(typeName, "[]"); ok {
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		var errs []Finding
+		for i, item := range node.Content {
+			errs = append(errs, checkFields(item, itemType, fmt.Sprintf("%s[%d]", path, i), filename)...)
+		}
+		return errs
+	}
+	fields, ok := fieldTables[typeName]
+	if !ok || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	entries := mapEntries(node)
+	for i := 0; i < len(entries); i += 2 {
+		k, v := entries[i], entries[i+1]
+		field := joinPath(path, k.Value)
+		fieldType, known := fields[k.Value]
+		if !known {
+			if s := closestName(k.Value, fields); s != "" {
+				errs = append(errs, errorAt(filename, k, "%s is not a known field of %s (did you mean '%s'?)", field, typeName, s))
+			} else {
+				errs = append(errs, errorAt(filename, k, "%s is not a known field of %s", field, typeName))
+			}
+			continue
+		}
+		if fieldType != "" {
+			errs = append(errs, checkFields(v, fieldType, field, filename)...)
+		}
+	}
+	return errs
+}
+
+// closestName returns the name in names nearest to key by edit distance,
+// or "" if none is close enough to be a likely typo.
+func closestName[V any](key string, names map[string]V) string {
+	best, bestDist := "", max(2, len(key)/4)+1
+	for f := range names {
+		if d := levenshtein(key, f); d < bestDist || d == bestDist && f < best {
+			best, bestDist = f, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single-byte insertions, deletions and
+// substitutions turning a into b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
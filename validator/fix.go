@@ -0,0 +1,347 @@
+package validator
+
+import (
+	"bytes"
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fix applies the fixes of the enabled rules to a YAML stream and returns
+// the rewritten stream with the number of values changed. When nothing
+// changes, data is returned as it is. Only the text of the changed values
+// is replaced, so the rest of the stream is kept byte for byte; should a
+// value not be found in the source as written, the stream is re-encoded,
+// which keeps comments and anchors but may change indentation and quoting.
+// JSON input is never fixed.
+func Fix(filename string, data []byte, cfg *Config) ([]byte, int, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".json") || startsObject(data) {
+		return data, 0, nil
+	}
+	var roots []*yaml.Node
+	before := map[*yaml.Node]scalarState{}
+	fixed := 0
+	err := decodeDocuments(filename, data, cfg, func(root *yaml.Node) {
+		roots = append(roots, root)
+		if isEmptyDocument(root) {
+			return
+		}
+		recordScalars(root, before)
+		objects := []*yaml.Node{root.Content[0]}
+		if objectKind(root.Content[0]) == listKind {
+			objects = listObjects(root.Content[0])
+		}
+		for _, obj := range objects {
+			d := &document{filename: filename, cfg: cfg, root: root, mapping: obj, copies: map[*yaml.Node]bool{}}
+			d.kind = objectKind(d.mapping)
+			d.spec, d.specPath, _ = findPodSpec(d.mapping, d.kind, filename)
+			for _, r := range rules {
+				if r.fix != nil && cfg.enabled(r) {
+					fixed += r.fix(d)
+				}
+			}
+		}
+	})
+	if err != nil || fixed == 0 {
+		return data, 0, err
+	}
+	if out, ok := spliceScalars(data, before); ok {
+		return out, fixed, nil
+	}
+	out, err := encodeDocuments(roots)
+	if err != nil {
+		return data, 0, err
+	}
+	return out, fixed, nil
+}
+
+// scalarState is what a fix may change of a scalar.
+type scalarState struct {
+	value, tag string
+	style      yaml.Style
+}
+
+func stateOf(node *yaml.Node) scalarState {
+	return scalarState{node.Value, node.Tag, node.Style}
+}
+
+// recordScalars records the state of the scalars below node, as written in
+// the source.
+func recordScalars(node *yaml.Node, states map[*yaml.Node]scalarState) {
+	if node.Kind == yaml.ScalarNode && node.Line > 0 {
+		states[node] = stateOf(node)
+	}
+	for _, child := range node.Content {
+		recordScalars(child, states)
+	}
+}
+
+// scalarEdit replaces the source text of a scalar, from offset start to
+// end, with text.
+type scalarEdit struct {
+	start, end int
+	text       string
+}
+
+// spliceScalars rewrites the text of the scalars whose state differs from
+// the one recorded in before, leaving the rest of data as it is. It
+// reports false if a scalar is not where and as its node says it is.
+func spliceScalars(data []byte, before map[*yaml.Node]scalarState) ([]byte, bool) {
+	bom := bytes.HasPrefix(data, utf8BOM)
+	src := bytes.TrimPrefix(data, utf8BOM)
+	lineStarts := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	var edits []scalarEdit
+	for node, old := range before {
+		if stateOf(node) == old {
+			continue
+		}
+		if node.Line > len(lineStarts) {
+			return nil, false
+		}
+		start := lineStarts[node.Line-1]
+		// Columns count characters, not bytes
+		for i := 1; i < node.Column; i++ {
+			_, size := utf8.DecodeRune(src[start:])
+			if size == 0 {
+				return nil, false
+			}
+			start += size
+		}
+		// An anchor is kept, but an explicit tag could contradict the new
+		// value, so such scalars are left to the encoder
+		if src[start] == '&' {
+			n := bytes.IndexAny(src[start:], " \t\r\n")
+			if n < 0 {
+				return nil, false
+			}
+			for start += n; start < len(src) && (src[start] == ' ' || src[start] == '\t'); start++ {
+			}
+		}
+		if start >= len(src) || src[start] == '!' {
+			return nil, false
+		}
+		end, ok := scalarEnd(src, start, old)
+		if !ok {
+			return nil, false
+		}
+		out, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value, Tag: node.Tag, Style: node.Style})
+		text := strings.TrimSuffix(string(out), "\n")
+		if err != nil || strings.ContainsAny(text, "\n") {
+			return nil, false
+		}
+		edits = append(edits, scalarEdit{start, end, text})
+	}
+	slices.SortFunc(edits, func(a, b scalarEdit) int { return cmp.Compare(a.start, b.start) })
+	var buf bytes.Buffer
+	if bom {
+		buf.Write(utf8BOM)
+	}
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			return nil, false
+		}
+		buf.Write(src[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), true
+}
+
+// scalarEnd returns the offset just past the single-line scalar old that
+// starts at offset start of src, and reports whether the text there reads
+// back as old.
+func scalarEnd(src []byte, start int, old scalarState) (int, bool) {
+	lineEnd := bytes.IndexByte(src[start:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src) - start
+	}
+	line := src[start : start+lineEnd]
+	var raw []byte
+	switch old.style {
+	case yaml.DoubleQuotedStyle:
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == '"' {
+				raw = line[:i+1]
+				break
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				raw = line[:i+1]
+				break
+			}
+		}
+	case 0:
+		if !bytes.HasPrefix(line, []byte(old.value)) {
+			return 0, false
+		}
+		raw = line[:len(old.value)]
+		if rest := line[len(raw):]; len(rest) > 0 && !strings.ContainsRune(" \t\r,]}", rune(rest[0])) {
+			return 0, false
+		}
+	}
+	if len(raw) == 0 {
+		return 0, false
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil || len(doc.Content) != 1 || doc.Content[0].Value != old.value {
+		return 0, false
+	}
+	return start + len(raw), true
+}
+
+// encodeDocuments writes roots as a YAML stream indented by two spaces.
+func encodeDocuments(roots []*yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, root := range roots {
+		if err := enc.Encode(root); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fixCase sets a scalar that only differs from one of allowed by case to
+// that value, and reports whether it did.
+func fixCase(node *yaml.Node, allowed []string) int {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return 0
+	}
+	for _, a := range allowed {
+		if node.Value != a && strings.EqualFold(node.Value, a) {
+			node.Value = a
+			return 1
+		}
+	}
+	return 0
+}
+
+// fixBool turns a string spelling true or false into that boolean.
+func fixBool(node *yaml.Node) int {
+	if node == nil || node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		return 0
+	}
+	if v := strings.ToLower(node.Value); v == "true" || v == "false" {
+		node.Value, node.Tag, node.Style = v, "!!bool", 0
+		return 1
+	}
+	return 0
+}
+
+// fixContainers applies fn to every container of the pod spec of d.
+func fixContainers(d *document, fn func(contNode *yaml.Node) int) int {
+	if d.spec == nil {
+		return 0
+	}
+	fixed := 0
+	forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+		fixed += fn(contNode)
+	})
+	return fixed
+}
+
+func fixOS(d *document) int {
+	if d.spec == nil {
+		return 0
+	}
+	allowed := d.cfg.rule("os-value").Allowed
+	if len(allowed) == 0 {
+		allowed = defaultOSNames
+	}
+	osNode := findMapKey(d.spec, "os")
+	if osNode != nil && osNode.Kind == yaml.MappingNode {
+		osNode = findMapKey(osNode, "name")
+	}
+	return fixCase(osNode, allowed)
+}
+
+func fixHostNamespaces(d *document) int {
+	fixed := 0
+	for _, field := range hostNamespaceFields {
+		fixed += fixBool(findMapKey(d.spec, field))
+	}
+	return fixed
+}
+
+func fixRunAsNonRoot(d *document) int {
+	if d.spec == nil {
+		return 0
+	}
+	fixed := fixBool(findMapKey(findMapKey(d.spec, "securityContext"), "runAsNonRoot"))
+	return fixed + fixContainers(d, func(contNode *yaml.Node) int {
+		return fixBool(findMapKey(findMapKey(contNode, "securityContext"), "runAsNonRoot"))
+	})
+}
+
+func fixContainerProtocols(d *document) int {
+	return fixContainers(d, func(contNode *yaml.Node) int {
+		return fixPortProtocols(findMapKey(contNode, "ports"))
+	})
+}
+
+func fixServiceProtocols(d *document) int {
+	if d.kind != "Service" {
+		return 0
+	}
+	return fixPortProtocols(findMapKey(findMapKey(d.mapping, "spec"), "ports"))
+}
+
+func fixPortProtocols(portsNode *yaml.Node) int {
+	if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+		return 0
+	}
+	fixed := 0
+	for _, portNode := range portsNode.Content {
+		fixed += fixCase(findMapKey(portNode, "protocol"), portProtocols)
+	}
+	return fixed
+}
+
+func fixImagePullPolicy(d *document) int {
+	return fixContainers(d, func(contNode *yaml.Node) int {
+		return fixCase(findMapKey(contNode, "imagePullPolicy"), imagePullPolicies)
+	})
+}
+
+// fixMillicores writes decimal CPU amounts in millicores when cpu-quantity
+// is configured to ask for that.
+func fixMillicores(d *document) int {
+	if !d.cfg.rule("cpu-quantity").Millicores {
+		return 0
+	}
+	return fixContainers(d, func(contNode *yaml.Node) int {
+		fixed := 0
+		resNode := findMapKey(contNode, "resources")
+		for _, resType := range []string{"limits", "requests"} {
+			valNode := findMapKey(findMapKey(resNode, resType), "cpu")
+			if m, ok := decimalCPU(valNode); ok {
+				valNode.Value, valNode.Tag, valNode.Style = m, "!!str", 0
+				fixed++
+			}
+		}
+		return fixed
+	})
+}
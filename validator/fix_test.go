@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFixKeepsUnchangedText(t *testing.T) {
+	tests := []struct {
+		name      string
+		src, want string
+		fixed     int
+	}{
+		{
+			name: "four-space indent and comments",
+			src: `apiVersion: v1
+kind: Pod
+metadata:
+    name: a   # aligned comment
+spec:
+    hostNetwork: "True"
+    containers:
+    -   name: a
+        image: nginx:1.27
+        imagePullPolicy: always  # kept
+        ports:
+        - {containerPort: 80, protocol: tcp}
+        securityContext:
+            runAsNonRoot: 'true'
+`,
+			want: `apiVersion: v1
+kind: Pod
+metadata:
+    name: a   # aligned comment
+spec:
+    hostNetwork: true
+    containers:
+    -   name: a
+        image: nginx:1.27
+        imagePullPolicy: Always  # kept
+        ports:
+        - {containerPort: 80, protocol: TCP}
+        securityContext:
+            runAsNonRoot: true
+`,
+			fixed: 4,
+		},
+		{
+			name:  "CRLF line endings and a BOM",
+			src:   "\ufeffapiVersion: v1\r\nkind: Pod\r\nmetadata:\r\n  name: a\r\nspec:\r\n  containers:\r\n  - name: a\r\n    image: nginx:1.27\r\n    imagePullPolicy: never\r\n",
+			want:  "\ufeffapiVersion: v1\r\nkind: Pod\r\nmetadata:\r\n  name: a\r\nspec:\r\n  containers:\r\n  - name: a\r\n    image: nginx:1.27\r\n    imagePullPolicy: Never\r\n",
+			fixed: 1,
+		},
+		{
+			name: "anchored value",
+			src: `apiVersion: v1
+kind: Pod
+metadata:
+  name: a
+spec:
+  containers:
+  - name: a
+    image: nginx:1.27
+    imagePullPolicy: &policy ifnotpresent
+  - name: b
+    image: nginx:1.27
+    imagePullPolicy: *policy
+`,
+			want: `apiVersion: v1
+kind: Pod
+metadata:
+  name: a
+spec:
+  containers:
+  - name: a
+    image: nginx:1.27
+    imagePullPolicy: &policy IfNotPresent
+  - name: b
+    image: nginx:1.27
+    imagePullPolicy: *policy
+`,
+			fixed: 1,
+		},
+		{
+			name: "octal-looking label",
+			src: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  labels: {mode: 0755, zone: "1"}
+`,
+			want: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  labels: {mode: "0755", zone: "1"}
+`,
+			fixed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, n, err := Fix("test.yaml", []byte(tt.src), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.fixed {
+				t.Errorf("fixed %d values, want %d", n, tt.fixed)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Fix output:\n%s\nwant:\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+// A value with an explicit tag cannot be spliced, so the stream is
+// re-encoded, which still fixes it.
+func TestFixFallsBackToEncoding(t *testing.T) {
+	src := `apiVersion: v1
+kind: Pod
+metadata:
+  name: a
+spec:
+  hostPID: !!str "true"
+  containers:
+  - name: a
+    image: nginx:1.27
+`
+	out, n, err := Fix("test.yaml", []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || !strings.Contains(string(out), "hostPID: true\n") {
+		t.Errorf("Fix = %d values:\n%s", n, out)
+	}
+}
+
+func TestFixLeavesCleanInput(t *testing.T) {
+	src := "apiVersion: v1\nkind: Pod\nmetadata:\n    name: a\nspec:\n    containers:\n    -   name: a\n        image: nginx:1.27\n"
+	out, n, err := Fix("test.yaml", []byte(src), nil)
+	if err != nil || n != 0 || string(out) != src {
+		t.Errorf("Fix = %q, %d, %v; want the input unchanged", out, n, err)
+	}
+}
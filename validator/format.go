@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// objectKeyOrder and metadataKeyOrder list the keys that Format moves to
+// the front of an object and of its metadata, in this order. The other
+// keys keep their order.
+var (
+	objectKeyOrder   = []string{"apiVersion", "kind", "metadata", "spec"}
+	metadataKeyOrder = []string{"name", "generateName", "namespace", "labels", "annotations"}
+)
+
+// Format returns a YAML stream in canonical form: indented by two spaces,
+// with apiVersion, kind, metadata and spec first in every object and the
+// name and namespace first in its metadata, and with single-line strings
+// only quoted where they would otherwise read as something else. Comments,
+// anchors and the order of the documents are kept. JSON input is returned
+// as it is.
+func Format(filename string, data []byte) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".json") || startsObject(data) {
+		return data, nil
+	}
+	var roots []*yaml.Node
+	err := decodeDocuments(filename, data, nil, func(root *yaml.Node) {
+		roots = append(roots, root)
+		if isEmptyDocument(root) {
+			return
+		}
+		objects := []*yaml.Node{root.Content[0]}
+		if objectKind(root.Content[0]) == listKind {
+			objects = append(objects, listObjects(root.Content[0])...)
+		}
+		for _, obj := range objects {
+			if obj.Kind != yaml.MappingNode {
+				continue
+			}
+			sortLeadingKeys(obj, objectKeyOrder)
+			if metaNode := findMapKey(obj, "metadata"); metaNode != nil && metaNode.Kind == yaml.MappingNode {
+				sortLeadingKeys(metaNode, metadataKeyOrder)
+			}
+		}
+		unquoteScalars(root)
+	})
+	if err != nil {
+		return data, err
+	}
+	if len(roots) == 0 {
+		return data, nil
+	}
+	return encodeDocuments(roots)
+}
+
+// sortLeadingKeys moves the entries of keys to the front of a mapping, in
+// the order of keys.
+func sortLeadingKeys(mapping *yaml.Node, keys []string) {
+	var lead, rest []*yaml.Node
+	for _, key := range keys {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if k := mapping.Content[i]; k.Kind == yaml.ScalarNode && k.Value == key {
+				lead = append(lead, k, mapping.Content[i+1])
+				break
+			}
+		}
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k := mapping.Content[i]
+		if k.Kind != yaml.ScalarNode || !slices.Contains(keys, k.Value) {
+			rest = append(rest, k, mapping.Content[i+1])
+		}
+	}
+	if len(lead)+len(rest) != len(mapping.Content) || len(lead) == 0 {
+		return
+	}
+	// A comment heading the mapping stays at its head
+	if first := mapping.Content[0]; first != lead[0] && first.HeadComment != "" {
+		lead[0].HeadComment = strings.TrimSuffix(first.HeadComment+"\n"+lead[0].HeadComment, "\n")
+		first.HeadComment = ""
+	}
+	mapping.Content = append(lead, rest...)
+}
+
+// unquoteScalars drops the quotes of single-line strings, which the
+// encoder puts back for those that need them, such as "8080". Strings that
+// YAML 1.1 parsers read as something else, such as "no", stay quoted.
+func unquoteScalars(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		quoted := node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+		_, oldBool := yaml11Bools[node.Value]
+		if quoted && node.Tag == "!!str" && !oldBool && !sexagesimalRe.MatchString(node.Value) && !strings.ContainsAny(node.Value, "\n\r") {
+			node.Style &^= yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle
+		}
+		return
+	}
+	for _, child := range node.Content {
+		unquoteScalars(child)
+	}
+}
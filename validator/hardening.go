@@ -0,0 +1,117 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// presets lists the rule groups that can be turned on together.
+var presets = []string{"security", "references"}
+
+// Presets returns the names of the rule presets.
+func Presets() []string {
+	return append([]string(nil), presets...)
+}
+
+// CheckPreset reports a preset name that does not exist.
+func CheckPreset(name string) error {
+	for _, p := range presets {
+		if p == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown preset %q; valid presets are: %s", name, strings.Join(presets, ", "))
+}
+
+// effectiveSecurityField returns a securityContext field of the container,
+// or of the pod when the container does not set it.
+func effectiveSecurityField(d *document, c container, field string) *yaml.Node {
+	if n := findMapKey(findMapKey(c.node, "securityContext"), field); n != nil {
+		return n
+	}
+	return findMapKey(findMapKey(d.spec, "securityContext"), field)
+}
+
+func validateRunAsNonRoot(d *document, c container) []Finding {
+	valNode := effectiveSecurityField(d, c, "runAsNonRoot")
+	if isTrue(valNode) {
+		return nil
+	}
+	if valNode == nil {
+		valNode = c.node
+	}
+	return []Finding{warningAt(d.filename, valNode, "%s does not set securityContext.runAsNonRoot to true; set it on the container or the pod so the image cannot run as root", c.path)}
+}
+
+func validateReadOnlyRootFilesystem(d *document, c container) []Finding {
+	valNode := findMapKey(findMapKey(c.node, "securityContext"), "readOnlyRootFilesystem")
+	if isTrue(valNode) {
+		return nil
+	}
+	if valNode == nil {
+		valNode = c.node
+	}
+	return []Finding{warningAt(d.filename, valNode, "%s does not set securityContext.readOnlyRootFilesystem to true; set it and mount the paths the container writes to as volumes", c.path)}
+}
+
+func validateDropAllCapabilities(d *document, c container) []Finding {
+	dropNode := findMapKey(findMapKey(findMapKey(c.node, "securityContext"), "capabilities"), "drop")
+	if dropNode != nil && dropNode.Kind == yaml.SequenceNode {
+		for _, item := range dropNode.Content {
+			if item.Kind == yaml.ScalarNode && item.Value == "ALL" {
+				return nil
+			}
+		}
+	}
+	at := c.node
+	if dropNode != nil {
+		at = dropNode
+	}
+	return []Finding{warningAt(d.filename, at, "%s does not drop ALL capabilities; add ALL to securityContext.capabilities.drop and add back only those the container needs", c.path)}
+}
+
+func validateNoHostPath(specNode *yaml.Node, specPath, filename string) []Finding {
+	volsNode := findMapKey(specNode, "volumes")
+	if volsNode == nil || volsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	for i, vol := range volsNode.Content {
+		if findMapKey(vol, "hostPath") != nil {
+			errs = append(errs, warningAt(filename, vol, "%s.volumes[%d] mounts a hostPath; use a persistentVolumeClaim, configMap or emptyDir so the pod cannot reach the node's files", specPath, i))
+		}
+	}
+	return errs
+}
+
+// validateNoHostNamespaces reports, once per pod, every host namespace the
+// pod shares.
+func validateNoHostNamespaces(specNode *yaml.Node, specPath, filename string) []Finding {
+	var fields []string
+	for _, field := range hostNamespaceFields {
+		if isTrue(findMapKey(specNode, field)) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	them := "them"
+	if len(fields) == 1 {
+		them = "it"
+	}
+	return []Finding{warningAt(filename, specNode, "%s enables %s; remove %s so the pod keeps its own namespaces", specPath, strings.Join(fields, ", "), them)}
+}
+
+func validateAutomountServiceAccountToken(specNode *yaml.Node, specPath, filename string) []Finding {
+	valNode := findMapKey(specNode, "automountServiceAccountToken")
+	switch {
+	case valNode == nil:
+		return []Finding{warningAt(filename, specNode, "%s.automountServiceAccountToken defaults to true; set it to false unless the pod calls the Kubernetes API", specPath)}
+	case isTrue(valNode):
+		return []Finding{warningAt(filename, valNode, "%s.automountServiceAccountToken is true; set it to false unless the pod calls the Kubernetes API", specPath)}
+	}
+	return nil
+}
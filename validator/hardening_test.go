@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSecurityPreset(t *testing.T) {
+	findings := validateFixture(t, "insecure-pod.yaml", &Config{Presets: []string{"security"}})
+	// The pod breaks each rule of the preset once
+	var ids []string
+	for _, r := range Rules() {
+		if r.Preset == "security" {
+			ids = append(ids, r.ID)
+		}
+	}
+	if len(ids) != 6 {
+		t.Errorf("the security preset has %d rules, want the 6 the fixture breaks", len(ids))
+	}
+	for _, id := range ids {
+		if got := ofRule(findings, id); len(got) != 1 {
+			t.Errorf("%s: %d findings, want 1: %v", id, len(got), brief(got))
+		}
+	}
+	var preset []Finding
+	for _, f := range findings {
+		if slices.Contains(ids, f.RuleID) {
+			preset = append(preset, f)
+		}
+	}
+	checkFindings(t, preset, []string{
+		"6:3 warning spec.automountServiceAccountToken defaults to true; set it to false unless the pod calls the Kubernetes API",
+		"6:3 warning spec enables hostNetwork, hostPID, hostIPC; remove them so the pod keeps its own namespaces",
+		"10:5 warning spec.containers[0] does not drop ALL capabilities; add ALL to securityContext.capabilities.drop and add back only those the container needs",
+		"10:5 warning spec.containers[0] does not set securityContext.readOnlyRootFilesystem to true; set it and mount the paths the container writes to as volumes",
+		"10:5 warning spec.containers[0] does not set securityContext.runAsNonRoot to true; set it on the container or the pod so the image cannot run as root",
+		"26:5 warning spec.volumes[0] mounts a hostPath; use a persistentVolumeClaim, configMap or emptyDir so the pod cannot reach the node's files",
+	})
+
+	// Without the preset, and with a rule of it turned off, they stay quiet
+	for _, id := range ids {
+		if got := ofRule(validateFixture(t, "insecure-pod.yaml", nil), id); len(got) != 0 {
+			t.Errorf("%s ran without the preset: %v", id, brief(got))
+		}
+	}
+	cfg := &Config{Presets: []string{"security"}, Rules: map[string]RuleConfig{"no-host-path": {Severity: SeverityOff}}}
+	if got := ofRule(validateFixture(t, "insecure-pod.yaml", cfg), "no-host-path"); len(got) != 0 {
+		t.Errorf("no-host-path ran although turned off: %v", brief(got))
+	}
+}
@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hpaMetricSources maps each metric type of an autoscaling/v2
+// HorizontalPodAutoscaler to the field holding its source.
+var hpaMetricSources = map[string]string{
+	"Resource":          "resource",
+	"ContainerResource": "containerResource",
+	"Pods":              "pods",
+	"Object":            "object",
+	"External":          "external",
+}
+
+var hpaTargetTypes = []string{"Utilization", "Value", "AverageValue"}
+
+// isHPA reports whether d is an autoscaling/v2 HorizontalPodAutoscaler.
+// Older apiVersions have metrics of another shape.
+func isHPA(d *document) bool {
+	apiVersionNode := findMapKey(d.mapping, "apiVersion")
+	return d.kind == "HorizontalPodAutoscaler" && apiVersionNode != nil && apiVersionNode.Value == "autoscaling/v2"
+}
+
+// validateHPA checks the replica bounds, scaleTargetRef and metrics of a
+// HorizontalPodAutoscaler.
+func validateHPA(d *document) []Finding {
+	if !isHPA(d) {
+		return nil
+	}
+	filename := d.filename
+	specNode := findMapKey(d.mapping, "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	minReplicas := int64(1)
+	if minNode := findMapKey(specNode, "minReplicas"); minNode != nil {
+		if minErrs := validateMinInt(minNode, "spec.minReplicas", 1, filename); len(minErrs) > 0 {
+			errs = append(errs, minErrs...)
+		} else {
+			minReplicas, _ = parseIntScalar(minNode)
+		}
+	}
+	if maxNode, missing := requireKey(filename, specNode, "spec", "maxReplicas"); maxNode == nil {
+		errs = append(errs, missing)
+	} else if maxErrs := validateMinInt(maxNode, "spec.maxReplicas", 1, filename); len(maxErrs) > 0 {
+		errs = append(errs, maxErrs...)
+	} else if maxReplicas, _ := parseIntScalar(maxNode); maxReplicas < minReplicas {
+		errs = append(errs, errorAt(filename, maxNode, "spec.maxReplicas %d must not be less than spec.minReplicas %d", maxReplicas, minReplicas))
+	}
+	if refNode, missing := requireKey(filename, specNode, "spec", "scaleTargetRef"); refNode == nil {
+		errs = append(errs, missing)
+	} else {
+		for _, key := range []string{"kind", "name"} {
+			if n, missing := requireKey(filename, refNode, "spec.scaleTargetRef", key); n == nil {
+				errs = append(errs, missing)
+			}
+		}
+	}
+	metricsNode := findMapKey(specNode, "metrics")
+	if metricsNode == nil || metricsNode.Kind != yaml.SequenceNode {
+		return errs
+	}
+	for i, metricNode := range metricsNode.Content {
+		errs = append(errs, validateHPAMetric(metricNode, fmt.Sprintf("spec.metrics[%d]", i), filename)...)
+	}
+	return errs
+}
+
+// validateHPAMetric checks the type of a metric, that it sets the source
+// of that type, and the target of the source.
+func validateHPAMetric(metricNode *yaml.Node, field, filename string) []Finding {
+	if metricNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	typeNode, missing := requireKey(filename, metricNode, field, "type")
+	if typeNode == nil {
+		return []Finding{missing}
+	}
+	source, ok := hpaMetricSources[typeNode.Value]
+	if !ok {
+		return validateEnum(typeNode, field+".type", []string{"Resource", "ContainerResource", "Pods", "Object", "External"}, filename)
+	}
+	sourceNode, missing := requireKey(filename, metricNode, field, source)
+	if sourceNode == nil {
+		return []Finding{missing}
+	}
+	field += "." + source
+	targetNode, missing := requireKey(filename, sourceNode, field, "target")
+	if targetNode == nil {
+		return []Finding{missing}
+	}
+	field += ".target"
+	var errs []Finding
+	if targetType := findMapKey(targetNode, "type"); targetType == nil {
+		errs = append(errs, missingAt(filename, targetNode, field, "type", "is required"))
+	} else {
+		errs = append(errs, validateEnum(targetType, field+".type", hpaTargetTypes, filename)...)
+	}
+	if utilNode := findMapKey(targetNode, "averageUtilization"); utilNode != nil {
+		if utilErrs := validateMinInt(utilNode, field+".averageUtilization", 1, filename); len(utilErrs) > 0 {
+			errs = append(errs, utilErrs...)
+		} else if v, _ := parseIntScalar(utilNode); v > 100 {
+			errs = append(errs, warningAt(filename, utilNode, "%s.averageUtilization %d is more than 100 percent of the requests", field, v))
+		}
+	}
+	return errs
+}
+
+// validateHPATarget relates autoscalers to the workloads they scale. For an
+// autoscaler it reports a target missing from a run that holds objects of
+// the target's kind, and for a workload it warns about spec.replicas, which
+// resets the replicas the autoscaler chose whenever the workload is
+// applied.
+func validateHPATarget(obj *Object, objs *objectIndex) []Finding {
+	d := obj.d
+	if isHPA(d) {
+		refNode := findMapKey(findMapKey(d.mapping, "spec"), "scaleTargetRef")
+		kindNode, nameNode := findMapKey(refNode, "kind"), findMapKey(refNode, "name")
+		if kindNode == nil || nameNode == nil || kindNode.Kind != yaml.ScalarNode || nameNode.Kind != yaml.ScalarNode {
+			return nil
+		}
+		candidates := objs.ofKind(kindNode.Value, obj.Namespace)
+		if len(candidates) == 0 || objs.find(kindNode.Value, obj.Namespace, nameNode.Value) != nil {
+			return nil
+		}
+		names := map[string]bool{}
+		for _, c := range candidates {
+			names[c.Name] = true
+		}
+		if s := closestName(nameNode.Value, names); s != "" {
+			return []Finding{errorAt(d.filename, nameNode, "spec.scaleTargetRef.name '%s' matches no %s among the checked files (did you mean '%s'?)", nameNode.Value, kindNode.Value, s)}
+		}
+		return []Finding{errorAt(d.filename, nameNode, "spec.scaleTargetRef.name '%s' matches no %s among the checked files", nameNode.Value, kindNode.Value)}
+	}
+	replicasNode := findMapKey(findMapKey(d.mapping, "spec"), "replicas")
+	if !replicatedKinds[d.kind] || replicasNode == nil {
+		return nil
+	}
+	for _, hpa := range objs.ofKind("HorizontalPodAutoscaler", obj.Namespace) {
+		if !isHPA(hpa.d) {
+			continue
+		}
+		refNode := findMapKey(findMapKey(hpa.d.mapping, "spec"), "scaleTargetRef")
+		kindNode, nameNode := findMapKey(refNode, "kind"), findMapKey(refNode, "name")
+		if kindNode != nil && nameNode != nil && kindNode.Value == d.kind && nameNode.Value == obj.Name {
+			return []Finding{warningAt(d.filename, replicasNode, "spec.replicas is set although HorizontalPodAutoscaler %s scales this %s; remove it so that applying the %s does not reset the replica count", hpa.Name, d.kind, d.kind)}
+		}
+	}
+	return nil
+}
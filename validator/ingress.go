@@ -0,0 +1,184 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ingressPathTypes = []string{"Exact", "Prefix", "ImplementationSpecific"}
+
+// validateIngress checks the rules, backends and TLS hosts of a
+// networking.k8s.io/v1 Ingress.
+func validateIngress(d *document) []Finding {
+	// Older apiVersions have a backend of another shape and are reported
+	// by deprecated-api
+	apiVersionNode := findMapKey(d.mapping, "apiVersion")
+	if d.kind != "Ingress" || apiVersionNode == nil || apiVersionNode.Value != "networking.k8s.io/v1" {
+		return nil
+	}
+	filename := d.filename
+	specNode := findMapKey(d.mapping, "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	defaultNode := findMapKey(specNode, "defaultBackend")
+	if defaultNode != nil {
+		errs = append(errs, validateIngressBackend(defaultNode, "spec.defaultBackend", filename)...)
+	}
+	rulesNode := findMapKey(specNode, "rules")
+	if defaultNode == nil && (rulesNode == nil || rulesNode.Kind == yaml.SequenceNode && len(rulesNode.Content) == 0) {
+		errs = append(errs, errorAt(filename, specNode, "spec must set defaultBackend or rules"))
+	}
+	var hosts []string
+	if rulesNode != nil && rulesNode.Kind == yaml.SequenceNode {
+		for i, ruleNode := range rulesNode.Content {
+			field := fmt.Sprintf("spec.rules[%d]", i)
+			if hostNode := findMapKey(ruleNode, "host"); hostNode != nil {
+				errs = append(errs, validateIngressHost(hostNode, field+".host", filename)...)
+				hosts = append(hosts, hostNode.Value)
+			}
+			httpNode := findMapKey(ruleNode, "http")
+			if httpNode == nil {
+				continue
+			}
+			pathsNode, missing := requireKey(filename, httpNode, field+".http", "paths")
+			if pathsNode == nil {
+				errs = append(errs, missing)
+				continue
+			}
+			if pathsNode.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, pathNode := range pathsNode.Content {
+				errs = append(errs, validateIngressPath(pathNode, fmt.Sprintf("%s.http.paths[%d]", field, j), filename)...)
+			}
+		}
+	}
+	tlsNode := findMapKey(specNode, "tls")
+	if tlsNode == nil || tlsNode.Kind != yaml.SequenceNode {
+		return errs
+	}
+	for i, entry := range tlsNode.Content {
+		field := fmt.Sprintf("spec.tls[%d]", i)
+		if secretNode := findMapKey(entry, "secretName"); secretNode != nil {
+			errs = append(errs, validateNameReference(secretNode, field+".secretName", filename)...)
+		}
+		hostsNode := findMapKey(entry, "hosts")
+		if hostsNode == nil || hostsNode.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, hostNode := range hostsNode.Content {
+			hostField := fmt.Sprintf("%s.hosts[%d]", field, j)
+			if herrs := validateIngressHost(hostNode, hostField, filename); len(herrs) > 0 {
+				errs = append(errs, herrs...)
+				continue
+			}
+			if !coversRuleHost(hostNode.Value, hosts) {
+				errs = append(errs, warningAt(filename, hostNode, "%s '%s' matches none of the hosts in spec.rules, so the certificate is never served", hostField, hostNode.Value))
+			}
+		}
+	}
+	return errs
+}
+
+// validateIngressPath checks an entry of the paths of an Ingress rule.
+func validateIngressPath(pathNode *yaml.Node, field, filename string) []Finding {
+	if pathNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	typeNode, missing := requireKey(filename, pathNode, field, "pathType")
+	if typeNode == nil {
+		errs = append(errs, missing)
+	} else {
+		errs = append(errs, validateEnum(typeNode, field+".pathType", ingressPathTypes, filename)...)
+	}
+	if pNode := findMapKey(pathNode, "path"); pNode != nil && typeNode != nil && typeNode.Value != "ImplementationSpecific" {
+		if pNode.Kind != yaml.ScalarNode || !strings.HasPrefix(pNode.Value, "/") {
+			errs = append(errs, errorAt(filename, pNode, "%s.path must start with '/' for pathType %s", field, typeNode.Value))
+		}
+	}
+	if backendNode, missing := requireKey(filename, pathNode, field, "backend"); backendNode == nil {
+		errs = append(errs, missing)
+	} else {
+		errs = append(errs, validateIngressBackend(backendNode, field+".backend", filename)...)
+	}
+	return errs
+}
+
+// validateIngressBackend checks that a backend names either a Service and
+// one of its ports, or a resource.
+func validateIngressBackend(backendNode *yaml.Node, field, filename string) []Finding {
+	if backendNode.Kind != yaml.MappingNode {
+		return []Finding{errorAt(filename, backendNode, "%s must be a mapping", field)}
+	}
+	serviceNode := findMapKey(backendNode, "service")
+	resourceNode := findMapKey(backendNode, "resource")
+	switch {
+	case serviceNode != nil && resourceNode != nil:
+		return []Finding{errorAt(filename, resourceNode, "%s must not set both service and resource", field)}
+	case resourceNode != nil:
+		return nil
+	case serviceNode == nil:
+		return []Finding{missingAt(filename, backendNode, field, "service", "is required")}
+	}
+	field += ".service"
+	var errs []Finding
+	if nameNode, missing := requireKey(filename, serviceNode, field, "name"); nameNode == nil {
+		errs = append(errs, missing)
+	} else if nameNode.Kind != yaml.ScalarNode || !isDNS1123Label(nameNode.Value) {
+		errs = append(errs, errorAt(filename, nameNode, "%s.name '%s' is not a valid DNS-1123 label", field, nameNode.Value))
+	}
+	portNode, missing := requireKey(filename, serviceNode, field, "port")
+	if portNode == nil {
+		return append(errs, missing)
+	}
+	numberNode, portNameNode := findMapKey(portNode, "number"), findMapKey(portNode, "name")
+	switch {
+	case numberNode != nil && portNameNode != nil:
+		errs = append(errs, errorAt(filename, portNameNode, "%s.port must not set both number and name", field))
+	case numberNode != nil:
+		errs = append(errs, validatePortNumber(numberNode, field+".port.number", filename)...)
+	case portNameNode != nil:
+		if !isIANASvcName(portNameNode.Value) {
+			errs = append(errs, errorAt(filename, portNameNode, "%s.port.name '%s' is not a valid port name", field, portNameNode.Value))
+		}
+	default:
+		errs = append(errs, errorAt(filename, portNode, "%s.port must set number or name", field))
+	}
+	return errs
+}
+
+// validateIngressHost checks that a host is a DNS name, optionally with a
+// wildcard as its first label.
+func validateIngressHost(hostNode *yaml.Node, field, filename string) []Finding {
+	if hostNode.Kind != yaml.ScalarNode || !isDNS1123Subdomain(strings.TrimPrefix(hostNode.Value, "*.")) {
+		return []Finding{errorAt(filename, hostNode, "%s '%s' is not a valid DNS name", field, hostNode.Value)}
+	}
+	return nil
+}
+
+// coversRuleHost reports whether the TLS host tlsHost matches one of the
+// rule hosts. Either may be a wildcard, which stands for exactly one label.
+func coversRuleHost(tlsHost string, hosts []string) bool {
+	for _, h := range hosts {
+		if h == tlsHost || wildcardMatches(tlsHost, h) || wildcardMatches(h, tlsHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatches reports whether pattern, a host such as *.example.com,
+// matches host.
+func wildcardMatches(pattern, host string) bool {
+	suffix, ok := strings.CutPrefix(pattern, "*")
+	if !ok {
+		return false
+	}
+	label, ok := strings.CutSuffix(host, suffix)
+	return ok && label != "" && !strings.Contains(label, ".")
+}
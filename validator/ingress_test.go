@@ -0,0 +1,50 @@
+package validator
+
+import "testing"
+
+func TestIngress(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"ingress-fanout.yaml", nil},
+		{"ingress-tls.yaml", []string{
+			"12:7 warning spec.tls[0].hosts[2] 'b.other.com' matches none of the hosts in spec.rules, so the certificate is never served",
+		}},
+		{"ingress-invalid.yaml", []string{
+			"10:15 error spec.rules[0].http.paths[0].path must start with '/' for pathType Prefix",
+			"14:19 error spec.rules[0].http.paths[0].backend.service.name 'Service_1' is not a valid DNS-1123 label",
+			"16:23 error spec.rules[0].http.paths[0].backend.service.port.number value out of range",
+			"18:19 error spec.rules[0].http.paths[1].pathType has unsupported value 'Regex'",
+			"21:13 error spec.rules[0].http.paths[1].backend.service.port is required",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			checkFindings(t, ofRule(validateFixture(t, tt.file, nil), "ingress"), tt.want)
+		})
+	}
+}
+
+func TestCoversRuleHost(t *testing.T) {
+	tests := []struct {
+		tlsHost string
+		hosts   []string
+		want    bool
+	}{
+		{"foo.example.com", []string{"foo.example.com"}, true},
+		{"*.example.com", []string{"foo.example.com"}, true},
+		{"foo.example.com", []string{"*.example.com"}, true},
+		{"*.example.com", []string{"*.example.com"}, true},
+		{"a.b.example.com", []string{"*.example.com"}, false},
+		{"*.example.com", []string{"a.b.example.com"}, false},
+		{"example.com", []string{"*.example.com"}, false},
+		{"foo.example.com", []string{"foo.example.org"}, false},
+		{"foo.example.com", nil, false},
+	}
+	for _, tt := range tests {
+		if got := coversRuleHost(tt.tlsHost, tt.hosts); got != tt.want {
+			t.Errorf("coversRuleHost(%q, %q) = %v, want %v", tt.tlsHost, tt.hosts, got, tt.want)
+		}
+	}
+}
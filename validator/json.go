@@ -0,0 +1,243 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// startsObject reports whether the first non-blank character of data
+// opens a JSON object.
+func startsObject(data []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+var utf8BOM = []byte("\ufeff")
+
+// ErrJSON is wrapped by the errors returned for input read as JSON that
+// cannot be parsed. Other parse errors come from the YAML decoder.
+var ErrJSON = errors.New("json")
+
+// jsonParser turns a stream of JSON values into YAML nodes carrying the
+// line and column where each value starts, so the rules can walk them as if
+// they were YAML. Columns count characters, as yaml.v3 does.
+type jsonParser struct {
+	data   []byte
+	pos    int
+	line   int
+	column int
+}
+
+// parseJSON returns one document node per top-level value of data.
+func parseJSON(data []byte) ([]*yaml.Node, error) {
+	p := &jsonParser{data: bytes.TrimPrefix(data, utf8BOM), line: 1, column: 1}
+	var docs []*yaml.Node
+	for {
+		p.skipSpace()
+		if p.pos == len(p.data) {
+			return docs, nil
+		}
+		node, err := p.value(0)
+		if err != nil {
+			return docs, err
+		}
+		docs = append(docs, &yaml.Node{Kind: yaml.DocumentNode, Line: node.Line, Column: node.Column, Content: []*yaml.Node{node}})
+	}
+}
+
+// maxJSONDepth bounds the nesting of arrays and objects.
+const maxJSONDepth = 1000
+
+func (p *jsonParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d, column %d: %s", ErrJSON, p.line, p.column, fmt.Sprintf(format, args...))
+}
+
+// advance moves past n bytes, none of which is a newline.
+func (p *jsonParser) advance(n int) {
+	p.column += utf8.RuneCount(p.data[p.pos : p.pos+n])
+	p.pos += n
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '\n':
+			p.pos++
+			p.line, p.column = p.line+1, 1
+		case ' ', '\t', '\r':
+			p.pos++
+			p.column++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) value(depth int) (*yaml.Node, error) {
+	if depth > maxJSONDepth {
+		return nil, p.errorf("exceeded max depth of %d", maxJSONDepth)
+	}
+	p.skipSpace()
+	if p.pos == len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	node := &yaml.Node{Line: p.line, Column: p.column}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.object(node, depth)
+	case c == '[':
+		return p.array(node, depth)
+	case c == '"':
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		node.Kind, node.Tag, node.Style, node.Value = yaml.ScalarNode, "!!str", yaml.DoubleQuotedStyle, s
+	case c == '-' || c >= '0' && c <= '9':
+		lit, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!int", lit
+		if strings.ContainsAny(lit, ".eE") {
+			node.Tag = "!!float"
+			if n, ok := exactInt(lit); ok {
+				node.Tag, node.Value = "!!int", n
+			}
+		}
+	default:
+		for _, lit := range []struct{ text, tag string }{{"true", "!!bool"}, {"false", "!!bool"}, {"null", "!!null"}} {
+			if bytes.HasPrefix(p.data[p.pos:], []byte(lit.text)) {
+				p.advance(len(lit.text))
+				node.Kind, node.Tag, node.Value = yaml.ScalarNode, lit.tag, lit.text
+				return node, nil
+			}
+		}
+		return nil, p.errorf("invalid character %q looking for beginning of value", c)
+	}
+	return node, nil
+}
+
+// exactInt returns the decimal digits of the number lit, such as 1e2 or
+// 100.0, when it is an integer that fits in 64 bits, so integer fields
+// written in those forms by JSON tools are checked as the integer they are.
+func exactInt(lit string) (string, bool) {
+	r, ok := new(big.Rat).SetString(lit)
+	if !ok || !r.IsInt() || !r.Num().IsInt64() {
+		return "", false
+	}
+	return r.Num().String(), true
+}
+
+func (p *jsonParser) object(node *yaml.Node, depth int) (*yaml.Node, error) {
+	node.Kind, node.Tag, node.Style = yaml.MappingNode, "!!map", yaml.FlowStyle
+	p.advance(1)
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.advance(1)
+		return node, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("expected string object key")
+		}
+		key, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos == len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after object key")
+		}
+		p.advance(1)
+		val, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, key, val)
+		if done, err := p.separator('}'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+func (p *jsonParser) array(node *yaml.Node, depth int) (*yaml.Node, error) {
+	node.Kind, node.Tag, node.Style = yaml.SequenceNode, "!!seq", yaml.FlowStyle
+	p.advance(1)
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.advance(1)
+		return node, nil
+	}
+	for {
+		item, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, item)
+		if done, err := p.separator(']'); err != nil || done {
+			return node, err
+		}
+	}
+}
+
+// separator consumes the comma between elements, or the closing bracket,
+// in which case it reports that the value is done.
+func (p *jsonParser) separator(end byte) (bool, error) {
+	p.skipSpace()
+	if p.pos == len(p.data) {
+		return false, p.errorf("unexpected end of input")
+	}
+	switch p.data[p.pos] {
+	case ',':
+		p.advance(1)
+		return false, nil
+	case end:
+		p.advance(1)
+		return true, nil
+	}
+	return false, p.errorf("expected ',' or '%c'", end)
+}
+
+// str consumes a string literal and returns its decoded value.
+func (p *jsonParser) str() (string, error) {
+	end := p.pos + 1
+	for ; end < len(p.data); end++ {
+		if c := p.data[end]; c == '\\' {
+			end++
+		} else if c == '"' || c == '\n' {
+			break
+		}
+	}
+	if end >= len(p.data) || p.data[end] != '"' {
+		return "", p.errorf("unterminated string")
+	}
+	var s string
+	if err := json.Unmarshal(p.data[p.pos:end+1], &s); err != nil {
+		return "", p.errorf("invalid string: %v", err)
+	}
+	p.advance(end + 1 - p.pos)
+	return s, nil
+}
+
+// number consumes a number literal and returns it as written.
+func (p *jsonParser) number() (string, error) {
+	end := p.pos
+	for end < len(p.data) && strings.IndexByte("+-.0123456789eE", p.data[end]) >= 0 {
+		end++
+	}
+	lit := string(p.data[p.pos:end])
+	if !json.Valid([]byte(lit)) {
+		return "", p.errorf("invalid number %q", lit)
+	}
+	p.advance(end - p.pos)
+	return lit, nil
+}
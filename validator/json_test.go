@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJSONManifests(t *testing.T) {
+	// Both files hold the same Pod, whose first port is written 8.08e3
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"pod-pretty.json", []string{
+			"11:18 warning spec.containers[0].image 'nginx:latest' uses the latest tag",
+			"17:30 error spec.containers[0].ports[1].containerPort value out of range",
+			"31:20 error spec.containers[0].resources.limits.cpu is not a valid quantity",
+		}},
+		{"pod-minified.json", []string{
+			"1:103 warning spec.containers[0].image 'nginx:latest' uses the latest tag",
+			"1:169 error spec.containers[0].ports[1].containerPort value out of range",
+			"1:293 error spec.containers[0].resources.limits.cpu is not a valid quantity",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			checkFindings(t, validateFixture(t, tt.file, nil), tt.want)
+		})
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	tests := []struct {
+		lit, tag, value string
+	}{
+		{"80", "!!int", "80"},
+		{"-3", "!!int", "-3"},
+		{"1e2", "!!int", "100"},
+		{"8.08E3", "!!int", "8080"},
+		{"100.0", "!!int", "100"},
+		{"1.5", "!!float", "1.5"},
+		{"1e-2", "!!float", "1e-2"},
+		{"1e30", "!!float", "1e30"},
+	}
+	for _, tt := range tests {
+		docs, err := parseJSON([]byte(`{"n": ` + tt.lit + `}`))
+		if err != nil {
+			t.Fatalf("parseJSON(%s): %v", tt.lit, err)
+		}
+		n := docs[0].Content[0].Content[1]
+		if n.Tag != tt.tag || n.Value != tt.value {
+			t.Errorf("%s parsed as %s %s, want %s %s", tt.lit, n.Tag, n.Value, tt.tag, tt.value)
+		}
+	}
+}
+
+func TestJSONSyntaxError(t *testing.T) {
+	tests := []struct {
+		file, src string
+		json      bool
+	}{
+		{"bad.json", `{"a": [1,}`, true},
+		{"bad.json", "a: [", true},
+		{"bad.yaml", `{"a": [1,}`, false},
+		{"bad.yaml", "a: [", false},
+	}
+	for _, tt := range tests {
+		_, err := ValidateWithConfig(tt.file, []byte(tt.src), nil)
+		if err == nil {
+			t.Fatalf("ValidateWithConfig(%s, %q) did not fail", tt.file, tt.src)
+		}
+		if got := errors.Is(err, ErrJSON); got != tt.json {
+			t.Errorf("ValidateWithConfig(%s, %q) = %v, wrapping ErrJSON = %v, want %v", tt.file, tt.src, err, got, tt.json)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The limits a Config applies when it sets none, which keep a hostile
+// stream, such as a billion-laughs chain of aliases, from exhausting memory
+// or the stack.
+const (
+	DefaultMaxBytes = 10 << 20
+	DefaultMaxNodes = 1_000_000
+	DefaultMaxDepth = 100
+)
+
+// ErrLimit is wrapped by the errors returned for input that exceeds the
+// size, node count or nesting depth limits of a Config.
+var ErrLimit = errors.New("input limit exceeded")
+
+func (c *Config) maxBytes() int64 {
+	if c == nil || c.MaxBytes == 0 {
+		return DefaultMaxBytes
+	}
+	return c.MaxBytes
+}
+
+// ByteLimit returns the size of the largest input c accepts, in bytes.
+func (c *Config) ByteLimit() int64 {
+	return c.maxBytes()
+}
+
+func (c *Config) maxNodes() int {
+	if c == nil || c.MaxNodes == 0 {
+		return DefaultMaxNodes
+	}
+	return c.MaxNodes
+}
+
+func (c *Config) maxDepth() int {
+	if c == nil || c.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return c.MaxDepth
+}
+
+// checkLimits returns an error wrapping ErrLimit if root, the doc'th
+// document of a stream, holds more nodes or nests deeper than cfg allows
+// once its aliases are expanded. Sizes are computed without expanding
+// anything, so checking a bomb is as cheap as checking its source.
+func checkLimits(root *yaml.Node, doc int, cfg *Config) error {
+	m := &nodeMeasure{maxNodes: cfg.maxNodes(), maxDepth: cfg.maxDepth(), memo: map[*yaml.Node]nodeSize{}, active: map[*yaml.Node]bool{}}
+	size := m.measure(root, 1)
+	switch {
+	case m.tooDeep:
+		return fmt.Errorf("%w: document %d is nested more than %d levels deep", ErrLimit, doc, m.maxDepth)
+	case size.nodes > m.maxNodes:
+		return fmt.Errorf("%w: document %d has more than %d nodes once aliases are expanded", ErrLimit, doc, m.maxNodes)
+	}
+	return nil
+}
+
+// nodeSize is the number of nodes of a subtree and its height.
+type nodeSize struct {
+	nodes, height int
+}
+
+// nodeMeasure sizes subtrees as expandAliases would leave them. Anchored
+// nodes are sized once however often they are aliased, and node counts
+// stop growing past maxNodes so they cannot overflow.
+type nodeMeasure struct {
+	maxNodes, maxDepth int
+	memo               map[*yaml.Node]nodeSize
+	// active holds the nodes being measured, as expandAliases leaves an
+	// alias to one of them in place.
+	active  map[*yaml.Node]bool
+	tooDeep bool
+}
+
+// measure returns the size of node, found at depth levels below the root.
+// Past maxDepth it gives up and sets tooDeep.
+func (m *nodeMeasure) measure(node *yaml.Node, depth int) nodeSize {
+	if depth > m.maxDepth {
+		m.tooDeep = true
+		return nodeSize{}
+	}
+	if node.Kind == yaml.AliasNode {
+		target := resolveAlias(node)
+		if target == nil || m.active[target] {
+			return nodeSize{nodes: 1, height: 1}
+		}
+		node = target
+	}
+	if size, ok := m.memo[node]; ok {
+		if depth+size.height-1 > m.maxDepth {
+			m.tooDeep = true
+		}
+		return size
+	}
+	m.active[node] = true
+	size := nodeSize{nodes: 1}
+	for _, child := range node.Content {
+		cs := m.measure(child, depth+1)
+		if m.tooDeep {
+			return nodeSize{}
+		}
+		size.nodes = min(size.nodes+cs.nodes, m.maxNodes+1)
+		size.height = max(size.height, cs.height)
+	}
+	size.height++
+	delete(m.active, node)
+	m.memo[node] = size
+	return size
+}
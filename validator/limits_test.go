@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// billionLaughs returns a stream whose levels each alias the one before
+// ten times, so that it expands to 10^levels scalars.
+func billionLaughs(levels int) string {
+	var b strings.Builder
+	b.WriteString("a0: &a0 lol\n")
+	for i := 1; i <= levels; i++ {
+		fmt.Fprintf(&b, "a%d: &a%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "*a%d", i-1)
+		}
+		b.WriteString("]\n")
+	}
+	return b.String()
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		cfg  *Config
+		want string
+	}{
+		{"oversize", "kind: Pod\n" + strings.Repeat("# padding\n", 20), &Config{MaxBytes: 100}, "input is larger than 100 bytes"},
+		{"deep flow nesting", strings.Repeat("[", 200) + strings.Repeat("]", 200), nil, "document 1 is nested more than 100 levels deep"},
+		{"deep block nesting", deepBlock(150), nil, "document 1 is nested more than 100 levels deep"},
+		{"alias bomb", billionLaughs(9), nil, "document 1 has more than 1000000 nodes once aliases are expanded"},
+		{"alias bomb in a later document", "kind: Pod\n---\n" + billionLaughs(9), nil, "document 2 has more than 1000000 nodes"},
+		{"node limit", "items: [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]\n", &Config{MaxNodes: 10}, "document 1 has more than 10 nodes"},
+		{"depth limit", "a: {b: {c: {d: 1}}}\n", &Config{MaxDepth: 4}, "document 1 is nested more than 4 levels deep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateWithConfig("test.yaml", []byte(tt.src), tt.cfg)
+			if !errors.Is(err, ErrLimit) {
+				t.Fatalf("error = %v, want one wrapping ErrLimit", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// deepBlock returns a block mapping nested depth levels deep.
+func deepBlock(depth int) string {
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		fmt.Fprintf(&b, "%sk:\n", strings.Repeat(" ", i))
+	}
+	fmt.Fprintf(&b, "%sk: v\n", strings.Repeat(" ", depth))
+	return b.String()
+}
+
+func TestLimitsAllowAliasesWithinBounds(t *testing.T) {
+	src := `apiVersion: v1
+kind: Pod
+metadata:
+  name: a
+spec:
+  containers:
+  - name: a
+    image: nginx:1.27
+    resources: &res
+      requests: {cpu: 100m, memory: 64Mi}
+      limits: {cpu: 100m, memory: 64Mi}
+  - name: b
+    image: nginx:1.27
+    resources: *res
+`
+	if _, err := ValidateWithConfig("test.yaml", []byte(src), nil); err != nil {
+		t.Fatalf("ValidateWithConfig: %v", err)
+	}
+	// Three levels of ten expand to a thousand nodes, within the limit
+	if _, err := ValidateWithConfig("test.yaml", []byte(billionLaughs(3)), nil); errors.Is(err, ErrLimit) {
+		t.Fatalf("ValidateWithConfig: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// listKind is the kind of the v1 List printed by kubectl get -o yaml, which
+// holds the objects under items.
+const listKind = "List"
+
+// validateList returns the findings for a List document. Each mapping of
+// items is validated as a document of its own, with the paths of its
+// findings starting at items[N]; skipped counts the items left out by kind.
+func validateList(root *yaml.Node, filename string, cfg *Config) (findings []Finding, skipped int, objects []*Object) {
+	d := &document{filename: filename, cfg: cfg, root: root, mapping: root.Content[0], kind: listKind, copies: map[*yaml.Node]bool{}}
+	findings = runRule(d, "list-items")
+	fillPaths(root, findings)
+	findings = applySuppressions(findings, collectSuppressions(root), false, filename)
+
+	itemsNode := findMapKey(d.mapping, "items")
+	if itemsNode == nil || itemsNode.Kind != yaml.SequenceNode {
+		return findings, 0, nil
+	}
+	for i, item := range itemsNode.Content {
+		item = resolveAlias(item)
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if !cfg.checksKind(objectKind(item)) {
+			skipped++
+			continue
+		}
+		prefix := fmt.Sprintf("items[%d]", i)
+		itemFindings, obj := validateDocument(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{item}}, filename, cfg)
+		findings = append(findings, prefixFindings(prefix, itemFindings)...)
+		if obj != nil {
+			obj.prefix = prefix
+			objects = append(objects, obj)
+		}
+	}
+	return findings, skipped, objects
+}
+
+// prefixFindings makes the findings of a List item name their paths from
+// the List, prefixing them with the item path, as in items[2].
+func prefixFindings(prefix string, findings []Finding) []Finding {
+	for i, f := range findings {
+		if startsWithField(f.Message, f.Path) {
+			findings[i].Message = prefix + "." + f.Message
+		}
+		findings[i].Path = joinItemPath(prefix, f.Path)
+	}
+	return findings
+}
+
+// listObjects returns the mappings among the items of a List.
+func listObjects(list *yaml.Node) []*yaml.Node {
+	itemsNode := findMapKey(list, "items")
+	if itemsNode == nil || itemsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var objects []*yaml.Node
+	for _, item := range itemsNode.Content {
+		if item = resolveAlias(item); item.Kind == yaml.MappingNode {
+			objects = append(objects, item)
+		}
+	}
+	return objects
+}
+
+// validateListItems requires a List to hold a list of mappings under items.
+func validateListItems(d *document) []Finding {
+	if d.kind != listKind {
+		return nil
+	}
+	itemsNode, missing := requireKey(d.filename, d.mapping, "", "items")
+	if itemsNode == nil {
+		return []Finding{missing}
+	}
+	if itemsNode.Kind != yaml.SequenceNode {
+		return []Finding{errorAt(d.filename, itemsNode, "items must be a list")}
+	}
+	var errs []Finding
+	for i, item := range itemsNode.Content {
+		if resolveAlias(item).Kind != yaml.MappingNode {
+			errs = append(errs, errorAt(d.filename, item, "items[%d] must be a mapping", i))
+		}
+	}
+	return errs
+}
+
+// joinItemPath prefixes the path of a finding within a List item with the
+// path of the item.
+func joinItemPath(prefix, path string) string {
+	if path == "" || strings.HasPrefix(path, "[") {
+		return prefix + path
+	}
+	return prefix + "." + path
+}
+
+// startsWithField reports whether msg starts with the first field of path,
+// so that the message names a field of the object it was found in.
+func startsWithField(msg, path string) bool {
+	field, _, _ := strings.Cut(path, ".")
+	field, _, _ = strings.Cut(field, "[")
+	if field == "" || !strings.HasPrefix(msg, field) {
+		return false
+	}
+	rest := msg[len(field):]
+	return rest == "" || strings.ContainsRune(".[ ", rune(rest[0]))
+}
@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeKeys(t *testing.T) {
+	findings := validateString(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - &base
+    name: web
+    image: nginx:1.27
+    readinessProbe:
+      tcpSocket:
+        port: 80
+    resources:
+      limits:
+        cpu: lots
+        memory: 128Mi
+  - <<: *base
+    name: sidecar
+  - <<: *base
+    name: shadow
+    resources:
+      limits:
+        cpu: 500m
+        memory: 128Mi
+`, nil)
+	// The sidecar gets the bad cpu through the merge, while shadow's own
+	// resources take the place of the merged ones
+	checkFindings(t, ofRule(findings, "cpu-quantity"), []string{
+		"15:14 error spec.containers[0].resources.limits.cpu is not a valid quantity",
+		"17:9 error spec.containers[1].resources.limits.cpu is not a valid quantity (reached via alias *base)",
+	})
+}
+
+func TestFindMapKeyMerges(t *testing.T) {
+	var doc yaml.Node
+	src := `
+a: &a {x: a, y: a}
+b: &b {x: b, z: b}
+one: {<<: *a, y: own}
+many: {<<: [*a, *b]}
+nested: {<<: {<<: *b, w: nested}}
+`
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+	tests := []struct {
+		mapping, key, want string
+	}{
+		{"one", "x", "a"},
+		{"one", "y", "own"},
+		{"many", "x", "a"},
+		{"many", "y", "a"},
+		{"many", "z", "b"},
+		{"nested", "z", "b"},
+		{"nested", "w", "nested"},
+		{"one", "z", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if v := findMapKey(findMapKey(root, tt.mapping), tt.key); v != nil {
+			got = v.Value
+		}
+		if got != tt.want {
+			t.Errorf("%s.%s = %q, want %q", tt.mapping, tt.key, got, tt.want)
+		}
+	}
+}
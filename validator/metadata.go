@@ -0,0 +1,107 @@
+package validator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateObjectHeader checks the fields every Kubernetes object carries:
+// apiVersion, kind and metadata.name. Missing fields are reported at the
+// root mapping.
+func validateObjectHeader(mapping *yaml.Node, filename string) []Finding {
+	if mapping.Kind != yaml.MappingNode {
+		return []Finding{errorAt(filename, mapping, "document must be a mapping")}
+	}
+	var errs []Finding
+	for _, key := range []string{"apiVersion", "kind"} {
+		if valNode, missing := requireKey(filename, mapping, "", key); valNode == nil {
+			errs = append(errs, missing)
+		} else if valNode.Kind != yaml.ScalarNode || valNode.Tag == "!!null" || valNode.Value == "" {
+			errs = append(errs, errorAt(filename, valNode, "%s must be a non-empty string", key))
+		}
+	}
+
+	metaNode, missing := requireKey(filename, mapping, "", "metadata")
+	if metaNode == nil {
+		return append(errs, missing)
+	}
+	if metaNode.Kind != yaml.MappingNode {
+		errs = append(errs, errorAt(filename, metaNode, "metadata must be a mapping"))
+		return errs
+	}
+	nameNode := findMapKey(metaNode, "name")
+	if nameNode == nil {
+		if findMapKey(metaNode, "generateName") == nil {
+			errs = append(errs, missingAt(filename, metaNode, "metadata", "name", "is required"))
+		}
+	} else if !isDNS1123Subdomain(nameNode.Value) {
+		errs = append(errs, errorAt(filename, nameNode, "metadata.name '%s' is not a valid DNS-1123 subdomain", nameNode.Value))
+	}
+	return errs
+}
+
+// validateObjectLabels checks the labels of the object and, for workloads,
+// of the pod template, whose metadata sits next to its spec.
+func validateObjectLabels(d *document) []Finding {
+	var errs []Finding
+	for _, m := range objectMetadata(d) {
+		errs = append(errs, validateLabels(m.node, m.path, d.filename)...)
+	}
+	return errs
+}
+
+// metadataNode is a metadata mapping with its field path.
+type metadataNode struct {
+	node *yaml.Node
+	path string
+}
+
+// objectMetadata returns the metadata of the object of d and, for kinds
+// with a pod template, the metadata of the template.
+func objectMetadata(d *document) []metadataNode {
+	var found []metadataNode
+	if metaNode := findMapKey(d.mapping, "metadata"); metaNode != nil {
+		found = append(found, metadataNode{metaNode, "metadata"})
+	}
+	if segments, ok := podSpecPaths[d.kind]; ok && d.kind != "Pod" {
+		metaPath := append(append([]string{}, segments[:len(segments)-1]...), "metadata")
+		metaNode := d.mapping
+		for _, seg := range metaPath {
+			metaNode = findMapKey(metaNode, seg)
+		}
+		if metaNode != nil {
+			found = append(found, metadataNode{metaNode, strings.Join(metaPath, ".")})
+		}
+	}
+	return found
+}
+
+// validateLabels checks the label and annotation keys of a metadata mapping,
+// and the label values. Annotation values are free-form.
+func validateLabels(metaNode *yaml.Node, field, filename string) []Finding {
+	var errs []Finding
+	errs = append(errs, validateLabelMap(findMapKey(metaNode, "labels"), field+".labels", true, filename)...)
+	errs = append(errs, validateLabelMap(findMapKey(metaNode, "annotations"), field+".annotations", false, filename)...)
+	return errs
+}
+
+// validateLabelMap checks that every key of mapNode is a qualified name and,
+// if checkValues is set, that every value is a valid label value.
+func validateLabelMap(mapNode *yaml.Node, field string, checkValues bool, filename string) []Finding {
+	if mapNode == nil || mapNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	entries := mapEntries(mapNode)
+	for i := 0; i < len(entries); i += 2 {
+		k, v := entries[i], entries[i+1]
+		if !isQualifiedName(k.Value) {
+			errs = append(errs, errorAt(filename, k, "%s key '%s' is not a valid qualified name", field, k.Value))
+		}
+		if checkValues && v.Kind == yaml.ScalarNode && !isLabelValue(v.Value) {
+			errs = append(errs, errorAt(filename, v, "%s value '%s' for key '%s' is not a valid label value", field, v.Value, k.Value))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of the
+// validation latency histogram.
+var latencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// findingLabels are the labels findings are counted by. File names are
+// never labels, so the number of series stays bounded by the rules.
+type findingLabels struct {
+	rule     string
+	severity Severity
+}
+
+// metrics counts the work of the validator since the process started, for
+// WriteMetrics.
+var metrics = struct {
+	sync.Mutex
+	requests, documents, parseFailures uint64
+	findings                           map[findingLabels]uint64
+	// latency counts validations by bucket, the last one being +Inf
+	latency      []uint64
+	latencySum   float64
+	latencyCount uint64
+}{findings: map[findingLabels]uint64{}, latency: make([]uint64, len(latencyBuckets)+1)}
+
+// CountRequest counts a request served by a server validating manifests.
+func CountRequest() {
+	metrics.Lock()
+	metrics.requests++
+	metrics.Unlock()
+}
+
+// recordStream counts the validation of a stream of documents that took
+// elapsed, and whether it could be parsed.
+func recordStream(documents int, findings []Finding, parsed bool, elapsed time.Duration) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.documents += uint64(documents)
+	if !parsed {
+		metrics.parseFailures++
+	}
+	countFindings(findings)
+	seconds := elapsed.Seconds()
+	i, _ := slices.BinarySearch(latencyBuckets, seconds)
+	metrics.latency[i]++
+	metrics.latencySum += seconds
+	metrics.latencyCount++
+}
+
+// recordFindings counts findings that were not found validating a stream.
+func recordFindings(findings []Finding) {
+	metrics.Lock()
+	countFindings(findings)
+	metrics.Unlock()
+}
+
+// countFindings counts the findings no lint-ignore comment silences. The
+// caller holds the lock.
+func countFindings(findings []Finding) {
+	for _, f := range findings {
+		if !f.Suppressed {
+			metrics.findings[findingLabels{f.RuleID, f.Severity}]++
+		}
+	}
+}
+
+// WriteMetrics writes the metrics of the validator in the Prometheus text
+// exposition format.
+func WriteMetrics(w io.Writer) error {
+	metrics.Lock()
+	defer metrics.Unlock()
+	b := bufio.NewWriter(w)
+	counter := func(name, help string, v uint64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("yamlvalidator_requests_total", "Requests served by the validating server.", metrics.requests)
+	counter("yamlvalidator_documents_validated_total", "Documents validated.", metrics.documents)
+	counter("yamlvalidator_parse_failures_total", "Streams that could not be parsed.", metrics.parseFailures)
+
+	fmt.Fprint(b, "# HELP yamlvalidator_findings_total Findings reported, by rule and severity.\n# TYPE yamlvalidator_findings_total counter\n")
+	labels := make([]findingLabels, 0, len(metrics.findings))
+	for l := range metrics.findings {
+		labels = append(labels, l)
+	}
+	slices.SortFunc(labels, func(a, b findingLabels) int {
+		return cmp.Or(cmp.Compare(a.rule, b.rule), cmp.Compare(a.severity, b.severity))
+	})
+	for _, l := range labels {
+		fmt.Fprintf(b, "yamlvalidator_findings_total{rule=%s,severity=%s} %d\n", strconv.Quote(l.rule), strconv.Quote(string(l.severity)), metrics.findings[l])
+	}
+
+	fmt.Fprint(b, "# HELP yamlvalidator_validation_duration_seconds Time taken to validate a stream.\n# TYPE yamlvalidator_validation_duration_seconds histogram\n")
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += metrics.latency[i]
+		fmt.Fprintf(b, "yamlvalidator_validation_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(b, "yamlvalidator_validation_duration_seconds_bucket{le=\"+Inf\"} %d\n", metrics.latencyCount)
+	fmt.Fprintf(b, "yamlvalidator_validation_duration_seconds_sum %s\n", strconv.FormatFloat(metrics.latencySum, 'g', -1, 64))
+	fmt.Fprintf(b, "yamlvalidator_validation_duration_seconds_count %d\n", metrics.latencyCount)
+	return b.Flush()
+}
@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+)
+
+var ianaSvcNameRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+var hasLetterRe = regexp.MustCompile(`[a-z]`)
+
+// isIANASvcName reports whether s is a valid IANA_SVC_NAME as used for named
+// container ports: at most 15 lowercase alphanumerics or '-', containing at
+// least one letter, with no leading, trailing or doubled '-'.
+func isIANASvcName(s string) bool {
+	return len(s) <= 15 && ianaSvcNameRe.MatchString(s) && hasLetterRe.MatchString(s) && !strings.Contains(s, "--")
+}
+
+var dns1123LabelRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// isDNS1123Label reports whether s is a valid RFC 1123 label: at most 63
+// lowercase alphanumerics or '-', starting and ending with an alphanumeric.
+func isDNS1123Label(s string) bool {
+	return len(s) <= 63 && dns1123LabelRe.MatchString(s)
+}
+
+var cIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isCIdentifier reports whether s is a valid C identifier.
+func isCIdentifier(s string) bool {
+	return cIdentifierRe.MatchString(s)
+}
+
+// isDNS1123Subdomain reports whether s is a valid RFC 1123 subdomain: at
+// most 253 characters made of dot-separated DNS-1123 labels.
+func isDNS1123Subdomain(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !isDNS1123Label(label) {
+			return false
+		}
+	}
+	return true
+}
+
+var qualifiedNameRe = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`)
+var labelValueRe = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+
+// isQualifiedName reports whether s is a valid label or annotation key: a
+// name of at most 63 characters with an optional DNS subdomain prefix
+// separated by '/'.
+func isQualifiedName(s string) bool {
+	name := s
+	if i := strings.Index(s, "/"); i >= 0 {
+		if !isDNS1123Subdomain(s[:i]) {
+			return false
+		}
+		name = s[i+1:]
+	}
+	return len(name) <= 63 && qualifiedNameRe.MatchString(name)
+}
+
+// isLabelValue reports whether s is a valid label value. Empty values are allowed.
+func isLabelValue(s string) bool {
+	return len(s) <= 63 && labelValueRe.MatchString(s)
+}
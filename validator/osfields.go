@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Linux-only securityContext fields the API server rejects when spec.os.name
+// is windows.
+var (
+	linuxOnlyPodSecurityFields = []string{
+		"seLinuxOptions", "seccompProfile", "fsGroup", "fsGroupChangePolicy",
+		"sysctls", "runAsUser", "runAsGroup", "supplementalGroups",
+	}
+	linuxOnlyContainerSecurityFields = []string{
+		"seLinuxOptions", "seccompProfile", "capabilities", "readOnlyRootFilesystem",
+		"privileged", "allowPrivilegeEscalation", "procMount", "runAsUser", "runAsGroup",
+	}
+)
+
+// podOSName returns the value of spec.os or spec.os.name, or "" if unset.
+func podOSName(specNode *yaml.Node) string {
+	osNode := findMapKey(specNode, "os")
+	if osNode == nil {
+		return ""
+	}
+	if osNode.Kind == yaml.MappingNode {
+		osNode = findMapKey(osNode, "name")
+	}
+	if osNode == nil || osNode.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return osNode.Value
+}
+
+// validateOSFields reports fields that are not allowed for the operating
+// system selected by spec.os, at the line of the conflicting field.
+func validateOSFields(specNode *yaml.Node, specPath, filename string) []Finding {
+	osName := podOSName(specNode)
+	if osName != "linux" && osName != "windows" {
+		return nil
+	}
+	var errs []Finding
+	check := func(scNode *yaml.Node, path string, linuxOnly []string) {
+		if scNode == nil || scNode.Kind != yaml.MappingNode {
+			return
+		}
+		if osName == "windows" {
+			for _, f := range linuxOnly {
+				if n := findMapKey(scNode, f); n != nil {
+					errs = append(errs, errorAt(filename, n, "%s.%s is not allowed when %s.os.name is windows", path, f, specPath))
+				}
+			}
+		} else if n := findMapKey(scNode, "windowsOptions"); n != nil {
+			errs = append(errs, errorAt(filename, n, "%s.windowsOptions is not allowed when %s.os.name is linux", path, specPath))
+		}
+	}
+	check(findMapKey(specNode, "securityContext"), specPath+".securityContext", linuxOnlyPodSecurityFields)
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		check(findMapKey(contNode, "securityContext"), path+".securityContext", linuxOnlyContainerSecurityFields)
+	})
+
+	if selNode := findMapKey(findMapKey(specNode, "nodeSelector"), "kubernetes.io/os"); selNode != nil && selNode.Value != osName {
+		errs = append(errs, warningAt(filename, selNode, "%s.nodeSelector kubernetes.io/os '%s' contradicts %s.os.name '%s'", specPath, selNode.Value, specPath, osName))
+	}
+	return errs
+}
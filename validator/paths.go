@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// plainKeyRe matches the mapping keys written as .key in a field path.
+// Other keys are written as ['key'].
+var plainKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// nodePaths returns the field path of every node of a document, such as
+// spec.containers[0].image, with keys sharing the path of their value. It
+// runs after aliases are expanded, so aliased values get the path where
+// they are used, and keys merged with << count as keys of the mapping they
+// are merged into.
+func nodePaths(root *yaml.Node) map[*yaml.Node]string {
+	paths := map[*yaml.Node]string{}
+	walkPaths(root, "", paths)
+	return paths
+}
+
+func walkPaths(node *yaml.Node, path string, paths map[*yaml.Node]string) {
+	if node == nil || node.Kind == yaml.AliasNode {
+		return
+	}
+	if _, seen := paths[node]; seen {
+		return
+	}
+	paths[node] = path
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkPaths(child, path, paths)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if isMergeKey(k) {
+				paths[k] = path
+				if v.Kind == yaml.SequenceNode {
+					paths[v] = path
+					for _, item := range v.Content {
+						walkPaths(item, path, paths)
+					}
+				} else {
+					walkPaths(v, path, paths)
+				}
+				continue
+			}
+			p := joinPath(path, k.Value)
+			paths[k] = p
+			walkPaths(v, p, paths)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkPaths(item, fmt.Sprintf("%s[%d]", path, i), paths)
+		}
+	}
+}
+
+// joinPath appends a mapping key to a field path.
+func joinPath(path, key string) string {
+	if !plainKeyRe.MatchString(key) {
+		return fmt.Sprintf("%s['%s']", path, strings.ReplaceAll(key, "'", `\'`))
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
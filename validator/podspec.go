@@ -0,0 +1,210 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+	"net"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	restartPolicies = []string{"Always", "OnFailure", "Never"}
+	dnsPolicies     = []string{"ClusterFirst", "ClusterFirstWithHostNet", "Default", "None"}
+)
+
+// podIntegerFields lists integer pod spec fields with their minimum value.
+var podIntegerFields = []struct {
+	field string
+	min   int64
+}{
+	{"terminationGracePeriodSeconds", 0},
+	{"activeDeadlineSeconds", 1},
+	{"priority", math.MinInt32},
+}
+
+func validatePodIntegers(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	for _, f := range podIntegerFields {
+		if valNode := findMapKey(specNode, f.field); valNode != nil {
+			errs = append(errs, validateMinInt(valNode, specPath+"."+f.field, f.min, filename)...)
+		}
+	}
+	return errs
+}
+
+func validateRestartPolicy(specNode *yaml.Node, specPath, filename string) []Finding {
+	policyNode := findMapKey(specNode, "restartPolicy")
+	if policyNode == nil {
+		return nil
+	}
+	return validateEnum(policyNode, specPath+".restartPolicy", restartPolicies, filename)
+}
+
+func validateDNSPolicy(specNode *yaml.Node, specPath, filename string) []Finding {
+	policyNode := findMapKey(specNode, "dnsPolicy")
+	if policyNode == nil {
+		return nil
+	}
+	if errs := validateEnum(policyNode, specPath+".dnsPolicy", dnsPolicies, filename); len(errs) > 0 {
+		return errs
+	}
+	switch policyNode.Value {
+	case "None":
+		// With dnsPolicy None the pod gets no resolvers unless dnsConfig provides them
+		var nameservers *yaml.Node
+		if dnsConfig := findMapKey(specNode, "dnsConfig"); dnsConfig != nil {
+			nameservers = findMapKey(dnsConfig, "nameservers")
+		}
+		if nameservers == nil || nameservers.Kind != yaml.SequenceNode || len(nameservers.Content) == 0 {
+			return []Finding{errorAt(filename, policyNode, "%s.dnsPolicy None requires dnsConfig.nameservers", specPath)}
+		}
+	case "ClusterFirstWithHostNet":
+		hostNetwork := findMapKey(specNode, "hostNetwork")
+		if hostNetwork == nil || hostNetwork.Value != "true" {
+			return []Finding{warningAt(filename, policyNode, "%s.dnsPolicy ClusterFirstWithHostNet has no effect without hostNetwork: true", specPath)}
+		}
+	}
+	return nil
+}
+
+// hostNamespaceFields lists the pod fields that share a host namespace.
+var hostNamespaceFields = []string{"hostNetwork", "hostPID", "hostIPC"}
+
+// validateHostNamespaces checks hostNetwork, hostPID and hostIPC. With
+// hostNetwork every hostPort must equal its containerPort.
+func validateHostNamespaces(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	for _, field := range hostNamespaceFields {
+		valNode := findMapKey(specNode, field)
+		if valNode == nil {
+			continue
+		}
+		if valNode.Kind != yaml.ScalarNode || valNode.Tag != "!!bool" {
+			errs = append(errs, errorAt(filename, valNode, "%s.%s must be boolean", specPath, field))
+		} else if isTrue(valNode) {
+			errs = append(errs, warningAt(filename, valNode, "%s.%s shares the host namespace with the pod", specPath, field))
+		}
+	}
+	if !isTrue(findMapKey(specNode, "hostNetwork")) {
+		return errs
+	}
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		portsNode := findMapKey(contNode, "ports")
+		if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, portNode := range portsNode.Content {
+			hostNode := findMapKey(portNode, "hostPort")
+			cpNode := findMapKey(portNode, "containerPort")
+			if hostNode != nil && cpNode != nil && hostNode.Value != cpNode.Value {
+				errs = append(errs, errorAt(filename, portNode, "%s.ports[%d].hostPort must equal containerPort when hostNetwork is true", path, i))
+			}
+		}
+	})
+	return errs
+}
+
+// validateHostAliases checks spec.hostAliases: each entry needs a unique IP
+// address and at least one valid hostname.
+func validateHostAliases(specNode *yaml.Node, specPath, filename string) []Finding {
+	aliasesNode := findMapKey(specNode, "hostAliases")
+	if aliasesNode == nil || aliasesNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	seen := map[string]bool{}
+	for i, alias := range aliasesNode.Content {
+		if alias.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.hostAliases[%d]", specPath, i)
+		ipNode, missing := requireKey(filename, alias, field, "ip")
+		if ipNode == nil {
+			errs = append(errs, missing)
+		} else if ip := net.ParseIP(ipNode.Value); ip == nil {
+			errs = append(errs, errorAt(filename, ipNode, "%s.ip '%s' is not a valid IP address", field, ipNode.Value))
+		} else {
+			if seen[ip.String()] {
+				errs = append(errs, errorAt(filename, ipNode, "%s.ip duplicates IP address '%s'", field, ipNode.Value))
+			}
+			seen[ip.String()] = true
+		}
+		hostsNode := findMapKey(alias, "hostnames")
+		if hostsNode == nil || hostsNode.Kind != yaml.SequenceNode || len(hostsNode.Content) == 0 {
+			errs = append(errs, errorAt(filename, alias, "%s.hostnames must not be empty", field))
+			continue
+		}
+		for j, host := range hostsNode.Content {
+			if !isDNS1123Subdomain(host.Value) {
+				errs = append(errs, errorAt(filename, host, "%s.hostnames[%d] '%s' is not a valid DNS-1123 subdomain", field, j, host.Value))
+			}
+		}
+	}
+	return errs
+}
+
+// validateNameReference requires node, the name of an object the pod
+// refers to, to be a non-empty DNS-1123 subdomain.
+func validateNameReference(node *yaml.Node, field, filename string) []Finding {
+	switch {
+	case node.Kind != yaml.ScalarNode || node.Tag == "!!null":
+		return []Finding{errorAt(filename, node, "%s must be a string", field)}
+	case node.Value == "":
+		return []Finding{errorAt(filename, node, "%s must not be empty", field)}
+	case !isDNS1123Subdomain(node.Value):
+		return []Finding{errorAt(filename, node, "%s '%s' is not a valid DNS-1123 subdomain", field, node.Value)}
+	}
+	return nil
+}
+
+// validatePodReferences checks the names of the objects a pod spec refers
+// to: its service account, priority class and image pull secrets.
+func validatePodReferences(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	for _, key := range []string{"serviceAccountName", "serviceAccount", "priorityClassName"} {
+		if valNode := findMapKey(specNode, key); valNode != nil {
+			errs = append(errs, validateNameReference(valNode, specPath+"."+key, filename)...)
+		}
+	}
+	secretsNode := findMapKey(specNode, "imagePullSecrets")
+	if secretsNode == nil {
+		return errs
+	}
+	if secretsNode.Kind != yaml.SequenceNode {
+		return append(errs, errorAt(filename, secretsNode, "%s.imagePullSecrets must be a list", specPath))
+	}
+	for i, entry := range secretsNode.Content {
+		field := fmt.Sprintf("%s.imagePullSecrets[%d]", specPath, i)
+		if entry.Kind != yaml.MappingNode {
+			errs = append(errs, errorAt(filename, entry, "%s must be a mapping such as {name: regcred}", field))
+			continue
+		}
+		entries := mapEntries(entry)
+		for j := 0; j < len(entries); j += 2 {
+			if k := entries[j]; k.Value != "name" {
+				errs = append(errs, errorAt(filename, k, "%s.%s is not allowed; entries hold only name", field, k.Value))
+			}
+		}
+		nameNode, missing := requireKey(filename, entry, field, "name")
+		if nameNode == nil {
+			errs = append(errs, missing)
+			continue
+		}
+		errs = append(errs, validateNameReference(nameNode, field+".name", filename)...)
+	}
+	return errs
+}
+
+// validateServiceAccountField warns about the deprecated spec.serviceAccount,
+// an alias of serviceAccountName.
+func validateServiceAccountField(specNode *yaml.Node, specPath, filename string) []Finding {
+	valNode := findMapKey(specNode, "serviceAccount")
+	if valNode == nil {
+		return nil
+	}
+	if nameNode := findMapKey(specNode, "serviceAccountName"); nameNode != nil && nameNode.Value != valNode.Value {
+		return []Finding{warningAt(filename, valNode, "%s.serviceAccount is deprecated and differs from serviceAccountName '%s'; remove it", specPath, nameNode.Value)}
+	}
+	return []Finding{warningAt(filename, valNode, "%s.serviceAccount is deprecated; use serviceAccountName", specPath)}
+}
@@ -0,0 +1,150 @@
+package validator
+
+import (
+	"fmt"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseIntScalar(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int64
+		ok   bool
+	}{
+		{"30", 30, true},
+		{"-5", -5, true},
+		{"0", 0, true},
+		{`"30"`, 30, true},
+		{"'30'", 30, true},
+		{"!!str 30", 30, true},
+		{"!!int 30", 30, true},
+		{"|\n  30\n", 30, true},
+		{">-\n  30\n", 30, true},
+		{"30s", 0, false},
+		{`"30s"`, 0, false},
+		{"3.0", 0, false},
+		{"!!float 30", 0, false},
+		{"true", 0, false},
+		{"~", 0, false},
+		{"|\n  3\n  0\n", 0, false},
+		{"[30]", 0, false},
+		{"99999999999999999999", 0, false},
+	}
+	for _, tt := range tests {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(tt.src), &doc); err != nil {
+			t.Fatalf("yaml.Unmarshal(%q): %v", tt.src, err)
+		}
+		got, ok := parseIntScalar(doc.Content[0])
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseIntScalar(%q) = %d, %v; want %d, %v", tt.src, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPodIntegers(t *testing.T) {
+	tests := []struct {
+		field, value string
+		want         []string
+	}{
+		{"terminationGracePeriodSeconds", "30", nil},
+		{"terminationGracePeriodSeconds", "0", nil},
+		{"terminationGracePeriodSeconds", `"30"`, nil},
+		{"terminationGracePeriodSeconds", "-1", []string{"6:34 error spec.terminationGracePeriodSeconds must be at least 0"}},
+		{"terminationGracePeriodSeconds", "30s", []string{"6:34 error spec.terminationGracePeriodSeconds must be a bare integer number of seconds, got '30s'"}},
+		{"activeDeadlineSeconds", "600", nil},
+		{"activeDeadlineSeconds", "0", []string{"6:26 error spec.activeDeadlineSeconds must be at least 1"}},
+		{"activeDeadlineSeconds", "1.5", []string{"6:26 error spec.activeDeadlineSeconds must be a bare integer number of seconds, got '1.5'"}},
+		{"priority", "1000", nil},
+		{"priority", "-2147483648", nil},
+		{"priority", "-2147483649", []string{"6:13 error spec.priority must be at least -2147483648"}},
+		{"priority", "high", []string{"6:13 error spec.priority must be an integer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  %s: %s
+  containers:
+  - name: web
+    image: nginx:1.27
+`, tt.field, tt.value), nil)
+			checkFindings(t, ofRule(findings, "pod-integers"), tt.want)
+		})
+	}
+}
+
+func TestPodReferences(t *testing.T) {
+	tests := []struct {
+		name, spec string
+		want       []string
+	}{
+		{"valid", "serviceAccountName: web\n  priorityClassName: high-priority\n  imagePullSecrets:\n  - name: regcred\n  - name: registry.example.com", nil},
+		{"empty", "serviceAccountName: \"\"\n  priorityClassName: ''", []string{
+			"6:23 error spec.serviceAccountName must not be empty",
+			"7:22 error spec.priorityClassName must not be empty",
+		}},
+		{"null", "serviceAccountName:\n  priorityClassName: ~", []string{
+			"6:22 error spec.serviceAccountName must be a string",
+			"7:22 error spec.priorityClassName must be a string",
+		}},
+		{"uppercase", "serviceAccountName: Web\n  priorityClassName: HIGH\n  imagePullSecrets:\n  - name: RegCred", []string{
+			"6:23 error spec.serviceAccountName 'Web' is not a valid DNS-1123 subdomain",
+			"7:22 error spec.priorityClassName 'HIGH' is not a valid DNS-1123 subdomain",
+			"9:11 error spec.imagePullSecrets[0].name 'RegCred' is not a valid DNS-1123 subdomain",
+		}},
+		{"secret entries", "imagePullSecrets:\n  - regcred\n  - {name: regcred, namespace: ci}\n  - {secret: regcred}\n  - name: \"\"", []string{
+			"7:5 error spec.imagePullSecrets[0] must be a mapping such as {name: regcred}",
+			"8:21 error spec.imagePullSecrets[1].namespace is not allowed; entries hold only name",
+			"9:5 error spec.imagePullSecrets[2].name is required",
+			"9:6 error spec.imagePullSecrets[2].secret is not allowed; entries hold only name",
+			"10:11 error spec.imagePullSecrets[3].name must not be empty",
+		}},
+		{"secrets not a list", "imagePullSecrets: regcred", []string{"6:21 error spec.imagePullSecrets must be a list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  %s
+  containers:
+  - name: web
+    image: nginx:1.27
+`, tt.spec), nil)
+			checkFindings(t, ofRule(findings, "pod-references"), tt.want)
+		})
+	}
+}
+
+func TestServiceAccountField(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"serviceAccountName: web", nil},
+		{"serviceAccount: web", []string{"6:19 warning spec.serviceAccount is deprecated; use serviceAccountName"}},
+		{"serviceAccountName: web\n  serviceAccount: web", []string{"7:19 warning spec.serviceAccount is deprecated; use serviceAccountName"}},
+		{"serviceAccountName: web\n  serviceAccount: api", []string{"7:19 warning spec.serviceAccount is deprecated and differs from serviceAccountName 'web'; remove it"}},
+	}
+	for _, tt := range tests {
+		findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  %s
+  containers:
+  - name: web
+    image: nginx:1.27
+`, tt.spec), nil)
+		checkFindings(t, ofRule(findings, "service-account-field"), tt.want)
+	}
+}
@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exempt reports whether the container is exempt from the rule id by one
+// of the name patterns in its exemptContainers option.
+func exempt(d *document, id string, c container) bool {
+	nameNode := findMapKey(c.node, "name")
+	if nameNode == nil || nameNode.Kind != yaml.ScalarNode {
+		return false
+	}
+	for _, pattern := range d.cfg.rule(id).ExemptContainers {
+		if ok, _ := path.Match(pattern, nameNode.Value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResourceRequirements reports containers that do not set cpu and
+// memory requests and limits, or only those the require option asks for.
+// Missing fields are reported at the closest mapping that exists.
+func validateResourceRequirements(d *document, c container) []Finding {
+	if exempt(d, "resource-requirements", c) {
+		return nil
+	}
+	resTypes := []string{"requests", "limits"}
+	if require := d.cfg.rule("resource-requirements").Require; require != "" {
+		resTypes = []string{require}
+	}
+	var errs []Finding
+	for _, resType := range resTypes {
+		for _, name := range []string{"cpu", "memory"} {
+			if at, rest := closestAncestor(c.node, "resources", resType, name); len(rest) > 0 {
+				f := warningAt(d.filename, at, "%s.resources.%s.%s should be set", c.path, resType, name)
+				f.missing = rest
+				errs = append(errs, f)
+			}
+		}
+	}
+	return errs
+}
+
+// validateReadinessProbe reports regular containers without a
+// readinessProbe. Init containers run to completion before the pod can be
+// ready, so they need none.
+func validateReadinessProbe(d *document, c container) []Finding {
+	if c.init || exempt(d, "readiness-probe", c) || findMapKey(c.node, "readinessProbe") != nil {
+		return nil
+	}
+	f := warningAt(d.filename, c.node, "%s.readinessProbe should be set", c.path)
+	f.missing = []string{"readinessProbe"}
+	return []Finding{f}
+}
+
+// validateIdenticalProbes reports a livenessProbe identical to the
+// readinessProbe, which restarts the container whenever it is merely not
+// ready.
+func validateIdenticalProbes(d *document, c container) []Finding {
+	if exempt(d, "identical-probes", c) {
+		return nil
+	}
+	liveness, readiness := findMapKey(c.node, "livenessProbe"), findMapKey(c.node, "readinessProbe")
+	if liveness == nil || readiness == nil {
+		return nil
+	}
+	if !sameNode(liveness, readiness) {
+		return nil
+	}
+	return []Finding{warningAt(d.filename, liveness, "%s.livenessProbe is identical to readinessProbe", c.path)}
+}
+
+// sameNode reports whether two nodes hold the same values, whatever their
+// style, anchors and comments.
+func sameNode(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Tag != b.Tag || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameNode(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
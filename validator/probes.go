@@ -0,0 +1,192 @@
+package validator
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// probeKinds lists the probe fields a container may declare.
+var probeKinds = []string{"readinessProbe", "livenessProbe", "startupProbe"}
+
+// probeHandlers lists the handler types a probe may use.
+var probeHandlers = []string{"exec", "httpGet", "tcpSocket", "grpc"}
+
+// lifecycleHooks lists the lifecycle hooks a container may declare, and
+// lifecycleHandlers the handler types they may use.
+var (
+	lifecycleHooks    = []string{"postStart", "preStop"}
+	lifecycleHandlers = []string{"exec", "httpGet", "tcpSocket", "sleep"}
+)
+
+func validateProbes(contNode *yaml.Node, path, filename string) []Finding {
+	var errs []Finding
+	for _, probe := range probeKinds {
+		probeNode := findMapKey(contNode, probe)
+		if probeNode != nil && probeNode.Kind == yaml.MappingNode {
+			errs = append(errs, validateHandler(contNode, probeNode, path+"."+probe, probeHandlers, filename)...)
+			errs = append(errs, validateProbeTiming(probeNode, probe, path+"."+probe, filename)...)
+		}
+	}
+	return errs
+}
+
+func validateLifecycle(contNode *yaml.Node, path, filename string) []Finding {
+	lcNode := findMapKey(contNode, "lifecycle")
+	if lcNode == nil || lcNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	for _, hook := range lifecycleHooks {
+		hookNode := findMapKey(lcNode, hook)
+		if hookNode != nil && hookNode.Kind == yaml.MappingNode {
+			errs = append(errs, validateHandler(contNode, hookNode, path+".lifecycle."+hook, lifecycleHandlers, filename)...)
+		}
+	}
+	return errs
+}
+
+// validateInitContainerProbes rejects probes on init containers. Only
+// restartable sidecars (restartPolicy: Always) keep running alongside the
+// main containers, so they are the only init containers that may be probed.
+func validateInitContainerProbes(contNode *yaml.Node, path, filename string) []Finding {
+	if policy := findMapKey(contNode, "restartPolicy"); policy != nil && policy.Value == "Always" {
+		return nil
+	}
+	var errs []Finding
+	for _, probe := range probeKinds {
+		if probeNode := findMapKey(contNode, probe); probeNode != nil {
+			errs = append(errs, errorAt(filename, probeNode, "%s.%s is only allowed on sidecar init containers with restartPolicy: Always; other init containers run to completion before the pod starts", path, probe))
+		}
+	}
+	return errs
+}
+
+// probeTimingMin holds the smallest value allowed for each probe timing field.
+var probeTimingMin = []struct {
+	field string
+	min   int64
+}{
+	{"initialDelaySeconds", 0},
+	{"periodSeconds", 1},
+	{"timeoutSeconds", 1},
+	{"failureThreshold", 1},
+	{"successThreshold", 1},
+}
+
+func validateProbeTiming(probeNode *yaml.Node, probe, field, filename string) []Finding {
+	var errs []Finding
+	for _, t := range probeTimingMin {
+		valNode := findMapKey(probeNode, t.field)
+		if valNode == nil {
+			continue
+		}
+		if tErrs := validateMinInt(valNode, field+"."+t.field, t.min, filename); len(tErrs) > 0 {
+			errs = append(errs, tErrs...)
+			continue
+		}
+		// Kubernetes only allows successThreshold: 1 on liveness and startup probes
+		if v, _ := parseIntScalar(valNode); t.field == "successThreshold" && probe != "readinessProbe" && v != 1 {
+			errs = append(errs, errorAt(filename, valNode, "%s.successThreshold must be 1", field))
+		}
+	}
+	return errs
+}
+
+// validateHandler checks that node declares exactly one of handlers and
+// validates the port of whichever network handler it uses.
+func validateHandler(contNode, node *yaml.Node, field string, handlers []string, filename string) []Finding {
+	var errs []Finding
+	var found []string
+	for _, h := range handlers {
+		if findMapKey(node, h) != nil {
+			found = append(found, h)
+		}
+	}
+	switch {
+	case len(found) == 0:
+		errs = append(errs, errorAt(filename, node, "%s must specify exactly one handler (%s)", field, strings.Join(handlers, ", ")))
+	case len(found) > 1:
+		errs = append(errs, errorAt(filename, node, "%s must specify exactly one handler, found %s", field, strings.Join(found, ", ")))
+	}
+
+	for _, h := range found {
+		hNode := findMapKey(node, h)
+		if hNode.Kind != yaml.MappingNode {
+			continue
+		}
+		switch h {
+		case "exec":
+			errs = append(errs, validateExecCommand(hNode, field+".exec", filename)...)
+			continue
+		case "sleep":
+			secsNode, missing := requireKey(filename, hNode, field+".sleep", "seconds")
+			if secsNode == nil {
+				errs = append(errs, missing)
+			} else if v, err := strconv.Atoi(secsNode.Value); secsNode.Kind != yaml.ScalarNode || err != nil || v < 1 {
+				errs = append(errs, errorAt(filename, secsNode, "%s.sleep.seconds must be a positive integer", field))
+			}
+			continue
+		}
+		portNode := findMapKey(hNode, "port")
+		if portNode == nil || portNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		portField := field + "." + h + ".port"
+		switch h {
+		case "httpGet", "tcpSocket":
+			errs = append(errs, validatePortRef(contNode, portNode, portField, filename)...)
+		case "grpc":
+			// grpc probes do not support named ports
+			errs = append(errs, validatePortNumber(portNode, portField, filename)...)
+		}
+	}
+	return errs
+}
+
+// validateExecCommand requires exec.command to be a non-empty list of strings.
+func validateExecCommand(execNode *yaml.Node, field, filename string) []Finding {
+	cmdNode := findMapKey(execNode, "command")
+	if cmdNode == nil || cmdNode.Kind != yaml.SequenceNode || len(cmdNode.Content) == 0 {
+		at := execNode
+		if cmdNode != nil {
+			at = cmdNode
+		}
+		return []Finding{errorAt(filename, at, "%s.command must be a non-empty list", field)}
+	}
+	var errs []Finding
+	for _, item := range cmdNode.Content {
+		if item.Kind != yaml.ScalarNode {
+			errs = append(errs, errorAt(filename, item, "%s.command items must be strings", field))
+		}
+	}
+	return errs
+}
+
+// validatePortRef checks a port that may be given either as a number or as
+// the name of one of the container's ports.
+func validatePortRef(contNode, portNode *yaml.Node, field, filename string) []Finding {
+	// Parse port as int and check range
+	portVal, err := strconv.Atoi(portNode.Value)
+	if err == nil {
+		if portVal < 1 || portVal > 65535 {
+			return []Finding{errorAt(filename, portNode, "%s value out of range", field)}
+		}
+		return nil
+	}
+	name := portNode.Value
+	if !isIANASvcName(name) {
+		return []Finding{errorAt(filename, portNode, "%s '%s' is not a valid port name", field, name)}
+	}
+	portsNode := findMapKey(contNode, "ports")
+	if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, p := range portsNode.Content {
+		if n := findMapKey(p, "name"); n != nil && n.Value == name {
+			return nil
+		}
+	}
+	return []Finding{errorAt(filename, portNode, "%s refers to unknown port name '%s'", field, name)}
+}
@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProbePorts(t *testing.T) {
+	// One container with all three probes; the findings name the probe
+	findings := validateString(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+    readinessProbe:
+      httpGet:
+        path: /ready
+        port: 8080
+    livenessProbe:
+      httpGet:
+        path: /live
+        port: 0
+    startupProbe:
+      httpGet:
+        path: /started
+        port: 70000
+  - name: sidecar
+    image: busybox:1.36
+    readinessProbe:
+      tcpSocket:
+        port: 65536
+    livenessProbe:
+      tcpSocket:
+        port: 65535
+    startupProbe:
+      tcpSocket:
+        port: 1
+`, nil)
+	checkFindings(t, ofRule(findings, "probes"), []string{
+		"16:15 error spec.containers[0].livenessProbe.httpGet.port value out of range",
+		"20:15 error spec.containers[0].startupProbe.httpGet.port value out of range",
+		"25:15 error spec.containers[1].readinessProbe.tcpSocket.port value out of range",
+	})
+}
+
+func TestNamedProbePorts(t *testing.T) {
+	findings := validateString(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+    ports:
+    - name: http
+      containerPort: 8080
+    readinessProbe:
+      httpGet:
+        path: /ready
+        port: http
+    livenessProbe:
+      httpGet:
+        path: /live
+        port: metrics
+    startupProbe:
+      tcpSocket:
+        port: HTTP_PORT
+  - name: sidecar
+    image: busybox:1.36
+    readinessProbe:
+      tcpSocket:
+        port: admin
+`, nil)
+	// The sidecar declares no ports, so any valid name may be served
+	checkFindings(t, ofRule(findings, "probes"), []string{
+		"19:15 error spec.containers[0].livenessProbe.httpGet.port refers to unknown port name 'metrics'",
+		"22:15 error spec.containers[0].startupProbe.tcpSocket.port 'HTTP_PORT' is not a valid port name",
+	})
+}
+
+func TestIsIANASvcName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"http", true},
+		{"http-alt", true},
+		{"h2c", true},
+		{"a", true},
+		{"abcdefghijklmno", true},
+		{"abcdefghijklmnop", false},
+		{"HTTP_PORT", false},
+		{"Http", false},
+		{"8080", false},
+		{"-http", false},
+		{"http-", false},
+		{"http--alt", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isIANASvcName(tt.name); got != tt.want {
+			t.Errorf("isIANASvcName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	tests := []struct {
+		name, hooks string
+		want        []string
+	}{
+		{"valid", "preStop:\n        sleep:\n          seconds: 5\n      postStart:\n        exec:\n          command: [touch, /ready]", nil},
+		{"no handler", "preStop: {}", []string{
+			"12:16 error spec.containers[0].lifecycle.preStop must specify exactly one handler (exec, httpGet, tcpSocket, sleep)",
+		}},
+		{"two handlers", "postStart:\n        exec:\n          command: [touch, /ready]\n        sleep:\n          seconds: 1", []string{
+			"13:9 error spec.containers[0].lifecycle.postStart must specify exactly one handler, found exec, sleep",
+		}},
+		{"empty command", "preStop:\n        exec:\n          command: []", []string{
+			"14:20 error spec.containers[0].lifecycle.preStop.exec.command must be a non-empty list",
+		}},
+		{"command not a list", "postStart:\n        exec:\n          command: touch /ready", []string{
+			"14:20 error spec.containers[0].lifecycle.postStart.exec.command must be a non-empty list",
+		}},
+		{"zero sleep", "preStop:\n        sleep:\n          seconds: 0", []string{
+			"14:20 error spec.containers[0].lifecycle.preStop.sleep.seconds must be a positive integer",
+		}},
+		{"sleep not an integer", "preStop:\n        sleep:\n          seconds: 5s", []string{
+			"14:20 error spec.containers[0].lifecycle.preStop.sleep.seconds must be a positive integer",
+		}},
+		{"named port", "preStop:\n        httpGet:\n          path: /drain\n          port: http\n      postStart:\n        httpGet:\n          path: /warm\n          port: admin", []string{
+			"19:17 error spec.containers[0].lifecycle.postStart.httpGet.port refers to unknown port name 'admin'",
+		}},
+		{"port out of range", "preStop:\n        tcpSocket:\n          port: 70000", []string{
+			"14:17 error spec.containers[0].lifecycle.preStop.tcpSocket.port value out of range",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+    ports:
+    - {name: http, containerPort: 8080}
+    lifecycle:
+      %s
+`, tt.hooks), nil)
+			checkFindings(t, ofRule(findings, "lifecycle"), tt.want)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+var (
+	errInvalidQuantity  = errors.New("is not a valid quantity")
+	errNegativeQuantity = errors.New("must not be negative")
+)
+
+// quantityRe splits a quantity into its signed decimal number and suffix.
+var quantityRe = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+))([A-Za-z]*)$`)
+
+// cpuSuffixes maps the suffixes allowed for cpu to their multiplier in cores.
+var cpuSuffixes = map[string]float64{
+	"":  1,
+	"m": 1e-3,
+}
+
+// memorySuffixes maps the suffixes allowed for memory to their multiplier in
+// bytes. Both "k" (the Kubernetes spelling) and "K" are accepted.
+var memorySuffixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"K":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+}
+
+// genericSuffixes holds every suffix Kubernetes accepts on a quantity.
+var genericSuffixes = map[string]float64{
+	"":   1,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// resourceSuffixes returns the suffix table used to parse the named resource.
+func resourceSuffixes(name string) map[string]float64 {
+	switch name {
+	case "cpu":
+		return cpuSuffixes
+	case "memory", "ephemeral-storage":
+		return memorySuffixes
+	}
+	return genericSuffixes
+}
+
+// parseQuantity parses a Kubernetes quantity such as "500m" or "0.5" and
+// returns its value in base units. Only suffixes listed in suffixes are accepted.
+func parseQuantity(s string, suffixes map[string]float64) (float64, error) {
+	m := quantityRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, errInvalidQuantity
+	}
+	mult, ok := suffixes[m[2]]
+	if !ok {
+		return 0, errInvalidQuantity
+	}
+	num, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, errInvalidQuantity
+	}
+	if num < 0 {
+		return 0, errNegativeQuantity
+	}
+	return num * mult, nil
+}
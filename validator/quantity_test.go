@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseMemoryQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		err  error
+	}{
+		{"0", 0, nil},
+		{"134217728", 134217728, nil},
+		{"128Mi", 128 << 20, nil},
+		{"1Ki", 1 << 10, nil},
+		{"1.5Gi", 1.5 * (1 << 30), nil},
+		{"2Ti", 2 << 40, nil},
+		{"500k", 500e3, nil},
+		{"500K", 500e3, nil},
+		{"64M", 64e6, nil},
+		{"1G", 1e9, nil},
+		{".5G", .5e9, nil},
+		{"+1Gi", 1 << 30, nil},
+		{"128MB", 0, errInvalidQuantity},
+		{"1.5.2Gi", 0, errInvalidQuantity},
+		{"1Gb", 0, errInvalidQuantity},
+		{"1 Gi", 0, errInvalidQuantity},
+		{"Gi", 0, errInvalidQuantity},
+		{"", 0, errInvalidQuantity},
+		{"1m", 0, errInvalidQuantity},
+		{"1T", 0, errInvalidQuantity},
+		{"-1Gi", 0, errNegativeQuantity},
+		{"-0.5", 0, errNegativeQuantity},
+	}
+	for _, tt := range tests {
+		got, err := parseQuantity(tt.in, memorySuffixes)
+		if err != tt.err || got != tt.want {
+			t.Errorf("parseQuantity(%q) = %v, %v; want %v, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestValidateMemory(t *testing.T) {
+	findings := validateString(t, `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+    resources:
+      requests:
+        memory: 128MB
+      limits:
+        memory: -1Gi
+  - name: sidecar
+    image: busybox:1.36
+    resources:
+      requests:
+        memory: 64Mi
+      limits:
+        memory: 1G
+`, nil)
+	checkFindings(t, ofRule(findings, "memory-quantity"), []string{
+		"11:17 error spec.containers[0].resources.requests.memory is not a valid quantity",
+		"13:17 error spec.containers[0].resources.limits.memory must not be negative",
+	})
+}
+
+func TestValidateCPU(t *testing.T) {
+	tests := []struct {
+		cpu  string
+		want []string
+	}{
+		{"500m", nil},
+		{"0.5", nil},
+		{`"2"`, nil},
+		// Zero is a valid amount to Kubernetes, so only negatives are rejected
+		{"0", nil},
+		{"abc", []string{"11:14 error spec.containers[0].resources.limits.cpu is not a valid quantity"}},
+		{"-1", []string{"11:14 error spec.containers[0].resources.limits.cpu must not be negative"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cpu, func(t *testing.T) {
+			findings := validateString(t, fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+    resources:
+      limits:
+        cpu: %s
+`, tt.cpu), nil)
+			checkFindings(t, ofRule(findings, "cpu-quantity"), tt.want)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Match is a value selected by Query.
+type Match struct {
+	// Path is the field path of the value, in the form used by
+	// Finding.Path, with wildcards replaced by what they matched.
+	Path   string
+	Line   int
+	Column int
+	// Document is the 1-based index of the document in a multi-document
+	// stream, and 0 when the stream holds one document.
+	Document int
+	// Value is the value of a scalar, or the YAML of a mapping or sequence.
+	Value string
+}
+
+// ErrInvalidPath is returned by Query for a path it cannot parse.
+var ErrInvalidPath = errors.New("invalid path")
+
+// pathSegment is one step of a query: a mapping key, a sequence index, or
+// a wildcard matching every key or item.
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses a field path such as spec.containers[0].image. Keys
+// holding dots or brackets are written quoted, as in
+// metadata.labels['app.kubernetes.io/name'] or
+// metadata.labels."app.kubernetes.io/name", and * or [*] matches every key
+// or item.
+func parsePath(expr string) ([]pathSegment, error) {
+	var segs []pathSegment
+	rest := expr
+	for first := true; rest != ""; first = false {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if rest[1:] != "" && (rest[1] == '\'' || rest[1] == '"') {
+				key, n, err := unquoteKey(rest[1:])
+				if err != nil {
+					return nil, fmt.Errorf("%w %q: %v", ErrInvalidPath, expr, err)
+				}
+				if !strings.HasPrefix(rest[1+n:], "]") {
+					return nil, fmt.Errorf("%w %q: missing ] after quoted key", ErrInvalidPath, expr)
+				}
+				segs = append(segs, pathSegment{key: key})
+				rest = rest[2+n:]
+				continue
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: missing ]", ErrInvalidPath, expr)
+			}
+			inner := rest[1:end]
+			if inner == "*" {
+				segs = append(segs, pathSegment{isIndex: true, wildcard: true})
+			} else if i, err := strconv.Atoi(inner); err == nil && i >= 0 {
+				segs = append(segs, pathSegment{isIndex: true, index: i})
+			} else {
+				return nil, fmt.Errorf("%w %q: bad index [%s]", ErrInvalidPath, expr, inner)
+			}
+			rest = rest[end+1:]
+		case rest[0] == '.' || first:
+			if !first {
+				rest = rest[1:]
+			}
+			if rest != "" && (rest[0] == '\'' || rest[0] == '"') {
+				key, n, err := unquoteKey(rest)
+				if err != nil {
+					return nil, fmt.Errorf("%w %q: %v", ErrInvalidPath, expr, err)
+				}
+				if rest[n:] != "" && rest[n] != '.' && rest[n] != '[' {
+					return nil, fmt.Errorf("%w %q: unexpected %q after quoted key", ErrInvalidPath, expr, rest[n])
+				}
+				segs = append(segs, pathSegment{key: key})
+				rest = rest[n:]
+				continue
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("%w %q: empty key", ErrInvalidPath, expr)
+			}
+			segs = append(segs, pathSegment{key: key, wildcard: key == "*"})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("%w %q: unexpected %q", ErrInvalidPath, expr, rest[0])
+		}
+	}
+	return segs, nil
+}
+
+// unquoteKey reads a quoted key at the start of s, in which the quote and
+// backslash are escaped with a backslash, and returns it with the number
+// of bytes it took.
+func unquoteKey(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated quoted key")
+}
+
+// Query returns the values at path in every document of a YAML or JSON
+// stream. Aliases are expanded and merge keys applied first, so values are
+// found where they are used.
+func Query(filename string, data []byte, path string) ([]Match, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]Match
+	decErr := decodeDocuments(filename, data, nil, func(root *yaml.Node) {
+		if isEmptyDocument(root) {
+			docs = append(docs, nil)
+			return
+		}
+		expandAliases(root, map[int]string{}, map[*yaml.Node]bool{}, map[*yaml.Node]bool{})
+		var matches []Match
+		selectPath(root.Content[0], "", segs, func(node *yaml.Node, p string) {
+			matches = append(matches, Match{Path: p, Line: node.Line, Column: node.Column, Value: nodeValue(node)})
+		})
+		docs = append(docs, matches)
+	})
+	var matches []Match
+	for i, m := range docs {
+		if len(docs) > 1 {
+			for j := range m {
+				m[j].Document = i + 1
+			}
+		}
+		matches = append(matches, m...)
+	}
+	return matches, decErr
+}
+
+// selectPath calls fn for every node below node that segs lead to.
+func selectPath(node *yaml.Node, path string, segs []pathSegment, fn func(node *yaml.Node, path string)) {
+	if len(segs) == 0 {
+		fn(node, path)
+		return
+	}
+	seg := segs[0]
+	switch {
+	case seg.isIndex:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			if seg.wildcard || i == seg.index {
+				selectPath(item, fmt.Sprintf("%s[%d]", path, i), segs[1:], fn)
+			}
+		}
+	case node.Kind == yaml.MappingNode:
+		entries := mapEntries(node)
+		for i := 0; i < len(entries); i += 2 {
+			if k := entries[i]; seg.wildcard || k.Value == seg.key {
+				selectPath(entries[i+1], joinPath(path, k.Value), segs[1:], fn)
+			}
+		}
+	}
+}
+
+// nodeValue renders a matched node: scalars as their value, everything else
+// as YAML.
+func nodeValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
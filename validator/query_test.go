@@ -0,0 +1,92 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []pathSegment
+	}{
+		{"spec.containers[0].image", []pathSegment{{key: "spec"}, {key: "containers"}, {isIndex: true}, {key: "image"}}},
+		{"spec.containers[*].name", []pathSegment{{key: "spec"}, {key: "containers"}, {isIndex: true, wildcard: true}, {key: "name"}}},
+		{"metadata.labels.*", []pathSegment{{key: "metadata"}, {key: "labels"}, {key: "*", wildcard: true}}},
+		{"metadata.labels['app.kubernetes.io/name']", []pathSegment{{key: "metadata"}, {key: "labels"}, {key: "app.kubernetes.io/name"}}},
+		{`metadata.labels."app.kubernetes.io/name"`, []pathSegment{{key: "metadata"}, {key: "labels"}, {key: "app.kubernetes.io/name"}}},
+		{`metadata.annotations.'a.b/c'.x`, []pathSegment{{key: "metadata"}, {key: "annotations"}, {key: "a.b/c"}, {key: "x"}}},
+		{`"a.b"[1]`, []pathSegment{{key: "a.b"}, {isIndex: true, index: 1}}},
+		{`a."it\"s"`, []pathSegment{{key: "a"}, {key: `it"s`}}},
+		{`a."*"`, []pathSegment{{key: "a"}, {key: "*"}}},
+	}
+	for _, tt := range tests {
+		got, err := parsePath(tt.expr)
+		if err != nil {
+			t.Errorf("parsePath(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePath(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"spec..containers",
+		"spec.containers[",
+		"spec.containers[-1]",
+		"spec.containers[x]",
+		"metadata.labels['a.b'",
+		`metadata.labels."a.b`,
+		`metadata.labels."a.b"c`,
+		"spec.",
+	} {
+		if _, err := parsePath(expr); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("parsePath(%q) error = %v, want ErrInvalidPath", expr, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	src := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app.kubernetes.io/name: web
+    tier: frontend
+spec:
+  template:
+    spec:
+      containers:
+      - name: a
+        image: nginx:1.27
+      - name: b
+        image: redis:7
+`
+	tests := []struct {
+		path string
+		want []Match
+	}{
+		{`metadata.labels."app.kubernetes.io/name"`, []Match{{Path: "metadata.labels['app.kubernetes.io/name']", Line: 6, Column: 29, Value: "web"}}},
+		{"metadata.labels['app.kubernetes.io/name']", []Match{{Path: "metadata.labels['app.kubernetes.io/name']", Line: 6, Column: 29, Value: "web"}}},
+		{"spec.template.spec.containers[*].image", []Match{
+			{Path: "spec.template.spec.containers[0].image", Line: 13, Column: 16, Value: "nginx:1.27"},
+			{Path: "spec.template.spec.containers[1].image", Line: 15, Column: 16, Value: "redis:7"},
+		}},
+		{"metadata.labels.app.kubernetes.io/name", nil},
+	}
+	for _, tt := range tests {
+		got, err := Query("test.yaml", []byte(src), tt.path)
+		if err != nil {
+			t.Errorf("Query(%q): %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Query(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
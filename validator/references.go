@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// objectRef is a reference from a pod spec to an object, such as the
+// ConfigMap of a volume.
+type objectRef struct {
+	kind string
+	// node holds the name and field its path.
+	node  *yaml.Node
+	field string
+}
+
+// podObjectRefs returns the references of the pod spec of d to ConfigMaps,
+// Secrets and PersistentVolumeClaims, leaving out the optional ones.
+func podObjectRefs(d *document) []objectRef {
+	if d.spec == nil {
+		return nil
+	}
+	var refs []objectRef
+	add := func(kind string, parent *yaml.Node, field, key string) {
+		if optional := findMapKey(parent, "optional"); optional != nil && optional.Value == "true" {
+			return
+		}
+		if n := findMapKey(parent, key); n != nil && n.Kind == yaml.ScalarNode && n.Value != "" {
+			refs = append(refs, objectRef{kind, n, field + "." + key})
+		}
+	}
+	volumesNode := findMapKey(d.spec, "volumes")
+	if volumesNode != nil && volumesNode.Kind == yaml.SequenceNode {
+		for i, vol := range volumesNode.Content {
+			field := fmt.Sprintf("%s.volumes[%d]", d.specPath, i)
+			add("ConfigMap", findMapKey(vol, "configMap"), field+".configMap", "name")
+			add("Secret", findMapKey(vol, "secret"), field+".secret", "secretName")
+			add("PersistentVolumeClaim", findMapKey(vol, "persistentVolumeClaim"), field+".persistentVolumeClaim", "claimName")
+			sourcesNode := findMapKey(findMapKey(vol, "projected"), "sources")
+			if sourcesNode == nil || sourcesNode.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, src := range sourcesNode.Content {
+				srcField := fmt.Sprintf("%s.projected.sources[%d]", field, j)
+				add("ConfigMap", findMapKey(src, "configMap"), srcField+".configMap", "name")
+				add("Secret", findMapKey(src, "secret"), srcField+".secret", "name")
+			}
+		}
+	}
+	forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+		if envFromNode := findMapKey(contNode, "envFrom"); envFromNode != nil && envFromNode.Kind == yaml.SequenceNode {
+			for i, entry := range envFromNode.Content {
+				field := fmt.Sprintf("%s.envFrom[%d]", path, i)
+				add("ConfigMap", findMapKey(entry, "configMapRef"), field+".configMapRef", "name")
+				add("Secret", findMapKey(entry, "secretRef"), field+".secretRef", "name")
+			}
+		}
+		if envNode := findMapKey(contNode, "env"); envNode != nil && envNode.Kind == yaml.SequenceNode {
+			for i, entry := range envNode.Content {
+				field := fmt.Sprintf("%s.env[%d].valueFrom", path, i)
+				valueFrom := findMapKey(entry, "valueFrom")
+				add("ConfigMap", findMapKey(valueFrom, "configMapKeyRef"), field+".configMapKeyRef", "name")
+				add("Secret", findMapKey(valueFrom, "secretKeyRef"), field+".secretKeyRef", "name")
+			}
+		}
+	})
+	return refs
+}
+
+// validateObjectRefs warns about the ConfigMaps, Secrets and
+// PersistentVolumeClaims a pod spec refers to that no object of the run
+// defines. They may still exist in the cluster, hence the warning.
+func validateObjectRefs(obj *Object, objs *objectIndex) []Finding {
+	var errs []Finding
+	for _, ref := range podObjectRefs(obj.d) {
+		if objs.find(ref.kind, obj.Namespace, ref.node.Value) == nil {
+			errs = append(errs, warningAt(obj.File, ref.node, "%s refers to %s %s, which none of the checked files define in namespace %s, so %s %s only starts if it exists in the cluster", ref.field, ref.kind, ref.node.Value, obj.Namespace, obj.Kind, obj.Name))
+		}
+	}
+	return errs
+}
+
+// podLabels returns the labels of the pods an object creates.
+func podLabels(d *document) *yaml.Node {
+	if _, ok := podSpecPaths[d.kind]; !ok {
+		return nil
+	}
+	meta := objectMetadata(d)
+	if len(meta) == 0 {
+		return nil
+	}
+	return findMapKey(meta[len(meta)-1].node, "labels")
+}
+
+// validateServiceSelectorTargets warns about a Service whose selector
+// matches the pod labels of no workload of the run.
+func validateServiceSelectorTargets(obj *Object, objs *objectIndex) []Finding {
+	if obj.Kind != "Service" {
+		return nil
+	}
+	selNode := findMapKey(findMapKey(obj.d.mapping, "spec"), "selector")
+	entries := mapEntries(selNode)
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, other := range objs.objects {
+		if other.Namespace != obj.Namespace {
+			continue
+		}
+		labels := podLabels(other.d)
+		if labels == nil {
+			continue
+		}
+		matches := true
+		for i := 0; i < len(entries); i += 2 {
+			v := findMapKey(labels, entries[i].Value)
+			if v == nil || v.Value != entries[i+1].Value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return nil
+		}
+	}
+	var pairs []string
+	for i := 0; i < len(entries); i += 2 {
+		pairs = append(pairs, entries[i].Value+"="+entries[i+1].Value)
+	}
+	sort.Strings(pairs)
+	return []Finding{warningAt(obj.File, selNode, "spec.selector %s of Service %s matches the pod labels of no workload the checked files define in namespace %s", strings.Join(pairs, ","), obj.Name, obj.Namespace)}
+}
@@ -0,0 +1,92 @@
+package validator
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Object is a validated object, kept so that the rules relating objects to
+// each other can run once every stream of a run has been validated.
+type Object struct {
+	File string
+	// Document is the number of the document holding the object, counted
+	// from 1, or 0 if the stream has a single document.
+	Document int
+	Kind     string
+	// Namespace is that of metadata.namespace, or default when it is
+	// unset, as for kubectl apply without --namespace.
+	Namespace, Name string
+
+	d    *document
+	sups []*suppression
+	// prefix is the path of the object within a List, as in items[2].
+	prefix string
+}
+
+func newObject(d *document, sups []*suppression) *Object {
+	obj := &Object{File: d.filename, Kind: d.kind, Namespace: "default", d: d, sups: sups}
+	metaNode := findMapKey(d.mapping, "metadata")
+	if n := findMapKey(metaNode, "name"); n != nil && n.Kind == yaml.ScalarNode {
+		obj.Name = n.Value
+	}
+	if n := findMapKey(metaNode, "namespace"); n != nil && n.Kind == yaml.ScalarNode && n.Value != "" {
+		obj.Namespace = n.Value
+	}
+	return obj
+}
+
+// objectIndex holds the objects of a run for the related checks.
+type objectIndex struct {
+	objects []*Object
+}
+
+// find returns the first object of the given kind and name in namespace.
+func (ix *objectIndex) find(kind, namespace, name string) *Object {
+	for _, obj := range ix.objects {
+		if obj.Kind == kind && obj.Name == name && obj.Namespace == namespace {
+			return obj
+		}
+	}
+	return nil
+}
+
+// ofKind returns the objects of the given kind in namespace.
+func (ix *objectIndex) ofKind(kind, namespace string) []*Object {
+	var found []*Object
+	for _, obj := range ix.objects {
+		if obj.Kind == kind && obj.Namespace == namespace {
+			found = append(found, obj)
+		}
+	}
+	return found
+}
+
+// CheckRelations runs the rules relating objects to each other, such as an
+// autoscaler and the workload it scales, over the objects of a run, which
+// may come from several streams. Each finding is reported in the object it
+// was found in, with the suppressions of that object applied.
+func CheckRelations(objects []*Object) []Finding {
+	ix := &objectIndex{objects: objects}
+	var findings []Finding
+	for _, obj := range objects {
+		d := obj.d
+		var fs []Finding
+		for _, r := range rules {
+			if r.checkRelated != nil && d.cfg.enabled(r) {
+				fs = append(fs, tagFindings(d.cfg, r, r.checkRelated(obj, ix))...)
+			}
+		}
+		fillPaths(d.root, fs)
+		setObjectLines(d.mapping, fs)
+		fs = applySuppressions(fs, obj.sups, false, d.filename)
+		if obj.prefix != "" {
+			fs = prefixFindings(obj.prefix, fs)
+		}
+		for i := range fs {
+			fs[i].Document = obj.Document
+		}
+		findings = append(findings, fs...)
+	}
+	findings = SortFindings(findings)
+	recordFindings(findings)
+	return findings
+}
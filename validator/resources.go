@@ -0,0 +1,255 @@
+package validator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func validateCPU(contNode *yaml.Node, path, filename string) []Finding {
+	return validateResourceQuantity(contNode, path, filename, "cpu", cpuSuffixes)
+}
+
+func validateMemory(contNode *yaml.Node, path, filename string) []Finding {
+	return validateResourceQuantity(contNode, path, filename, "memory", memorySuffixes)
+}
+
+// validateResourceQuantity checks resources.limits.<name> and
+// resources.requests.<name> of a container against the given suffix table.
+func validateResourceQuantity(contNode *yaml.Node, path, filename, name string, suffixes map[string]float64) []Finding {
+	var errs []Finding
+	resNode := findMapKey(contNode, "resources")
+	if resNode != nil && resNode.Kind == yaml.MappingNode {
+		for _, resType := range []string{"limits", "requests"} {
+			section := findMapKey(resNode, resType)
+			if section != nil && section.Kind == yaml.MappingNode {
+				valNode := findMapKey(section, name)
+				if valNode != nil && valNode.Kind == yaml.ScalarNode {
+					if _, err := parseQuantity(valNode.Value, suffixes); err != nil {
+						errs = append(errs, errorAt(filename, valNode, "%s.resources.%s.%s %v", path, resType, name, err))
+					}
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateMillicores reports CPU amounts written as decimal cores, such as
+// 0.5, that can be written in millicores instead.
+func validateMillicores(contNode *yaml.Node, path, filename string) []Finding {
+	var errs []Finding
+	resNode := findMapKey(contNode, "resources")
+	for _, resType := range []string{"limits", "requests"} {
+		valNode := findMapKey(findMapKey(resNode, resType), "cpu")
+		if m, ok := decimalCPU(valNode); ok {
+			errs = append(errs, errorAt(filename, valNode, "%s.resources.%s.cpu '%s' should be written in millicores as %s", path, resType, valNode.Value, m))
+		}
+	}
+	return errs
+}
+
+// decimalCPU returns a CPU amount with a fractional number of cores in
+// millicores, if it is a whole number of them.
+func decimalCPU(node *yaml.Node) (string, bool) {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	m := quantityRe.FindStringSubmatch(node.Value)
+	if m == nil || m[2] != "" || !strings.Contains(m[1], ".") {
+		return "", false
+	}
+	cores, err := strconv.ParseFloat(m[1], 64)
+	millis := math.Round(cores * 1000)
+	if err != nil || cores < 0 || math.Abs(cores*1000-millis) > 1e-6 || millis == math.Trunc(cores)*1000 {
+		return "", false
+	}
+	return strconv.FormatFloat(millis, 'f', -1, 64) + "m", true
+}
+
+// validateRequestsWithinLimits reports every resource whose request is
+// larger than its limit. Values that don't parse are reported elsewhere.
+func validateRequestsWithinLimits(contNode *yaml.Node, path, filename string) []Finding {
+	var errs []Finding
+	resNode := findMapKey(contNode, "resources")
+	if resNode == nil || resNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	requests := findMapKey(resNode, "requests")
+	limits := findMapKey(resNode, "limits")
+	if requests == nil || requests.Kind != yaml.MappingNode || limits == nil || limits.Kind != yaml.MappingNode {
+		return nil
+	}
+	entries := mapEntries(requests)
+	for i := 0; i < len(entries); i += 2 {
+		name := entries[i].Value
+		reqNode := entries[i+1]
+		limNode := findMapKey(limits, name)
+		if limNode == nil || reqNode.Kind != yaml.ScalarNode || limNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		suffixes := resourceSuffixes(name)
+		req, err := parseQuantity(reqNode.Value, suffixes)
+		if err != nil {
+			continue
+		}
+		lim, err := parseQuantity(limNode.Value, suffixes)
+		if err != nil {
+			continue
+		}
+		if req > lim {
+			errs = append(errs, errorAt(filename, reqNode, "%s.resources.requests.%s (%s) exceeds limit (%s)", path, name, reqNode.Value, limNode.Value))
+		}
+	}
+	return errs
+}
+
+// standardResources are the resource names without a domain that a
+// container may request, besides the hugepages-<size> family.
+var standardResources = map[string]bool{"cpu": true, "memory": true, "ephemeral-storage": true}
+
+// isHugePages reports whether name is a hugepages resource such as
+// hugepages-2Mi.
+func isHugePages(name string) bool {
+	return strings.HasPrefix(name, "hugepages-")
+}
+
+// isExtendedResource reports whether name is a resource advertised by a
+// device plugin or an operator, such as nvidia.com/gpu, rather than one in
+// the kubernetes.io domain.
+func isExtendedResource(name string) bool {
+	domain, _, ok := strings.Cut(name, "/")
+	return ok && domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// resourceSections returns the requests and limits mappings of a container,
+// either of which may be nil.
+func resourceSections(contNode *yaml.Node) (requests, limits *yaml.Node) {
+	resNode := findMapKey(contNode, "resources")
+	if requests = findMapKey(resNode, "requests"); requests != nil && requests.Kind != yaml.MappingNode {
+		requests = nil
+	}
+	if limits = findMapKey(resNode, "limits"); limits != nil && limits.Kind != yaml.MappingNode {
+		limits = nil
+	}
+	return requests, limits
+}
+
+// validateRequestEqualsLimit reports a resource that cannot be overcommitted
+// whose request is missing from limits or differs from its limit, at the
+// request. Values that don't parse are reported by the callers.
+func validateRequestEqualsLimit(reqNode *yaml.Node, limits *yaml.Node, path, name, filename string) []Finding {
+	field := joinPath(path+".resources.requests", name)
+	limNode := findMapKey(limits, name)
+	if limNode == nil {
+		return []Finding{errorAt(filename, reqNode, "%s must also be set in limits, as %s cannot be overcommitted", field, name)}
+	}
+	suffixes := resourceSuffixes(name)
+	req, reqErr := parseQuantity(reqNode.Value, suffixes)
+	lim, limErr := parseQuantity(limNode.Value, suffixes)
+	if reqErr == nil && limErr == nil && req != lim {
+		return []Finding{errorAt(filename, reqNode, "%s (%s) must equal its limit (%s)", field, reqNode.Value, limNode.Value)}
+	}
+	return nil
+}
+
+// validateHugePages checks the hugepages-<size> resources of a container:
+// their values must be quantities, requests must equal limits, and the
+// container must also ask for cpu or memory.
+func validateHugePages(contNode *yaml.Node, path, filename string) []Finding {
+	requests, limits := resourceSections(contNode)
+	var errs []Finding
+	var first *yaml.Node
+	for _, section := range []struct {
+		name string
+		node *yaml.Node
+	}{{"requests", requests}, {"limits", limits}} {
+		entries := mapEntries(section.node)
+		for i := 0; i < len(entries); i += 2 {
+			name, valNode := entries[i].Value, entries[i+1]
+			if !isHugePages(name) || valNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			field := joinPath(path+".resources."+section.name, name)
+			if first == nil {
+				first = valNode
+			}
+			if _, err := parseQuantity(strings.TrimPrefix(name, "hugepages-"), memorySuffixes); err != nil {
+				errs = append(errs, errorAt(filename, entries[i], "%s has a page size that is not a valid quantity", field))
+			}
+			if _, err := parseQuantity(valNode.Value, genericSuffixes); err != nil {
+				errs = append(errs, errorAt(filename, valNode, "%s %v", field, err))
+			} else if section.name == "requests" {
+				errs = append(errs, validateRequestEqualsLimit(valNode, limits, path, name, filename)...)
+			}
+		}
+	}
+	if first == nil {
+		return errs
+	}
+	for _, section := range []*yaml.Node{requests, limits} {
+		if findMapKey(section, "cpu") != nil || findMapKey(section, "memory") != nil {
+			return errs
+		}
+	}
+	return append(errs, errorAt(filename, first, "%s.resources sets hugepages but neither cpu nor memory; hugepages must come with a cpu or memory request or limit", path))
+}
+
+// validateExtendedResources checks the extended resources of a container,
+// such as nvidia.com/gpu: their values must be whole numbers and requests
+// must equal limits.
+func validateExtendedResources(contNode *yaml.Node, path, filename string) []Finding {
+	requests, limits := resourceSections(contNode)
+	var errs []Finding
+	for _, section := range []struct {
+		name string
+		node *yaml.Node
+	}{{"requests", requests}, {"limits", limits}} {
+		entries := mapEntries(section.node)
+		for i := 0; i < len(entries); i += 2 {
+			name, valNode := entries[i].Value, entries[i+1]
+			if !isExtendedResource(name) || valNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			field := joinPath(path+".resources."+section.name, name)
+			v, err := parseQuantity(valNode.Value, genericSuffixes)
+			switch {
+			case err != nil:
+				errs = append(errs, errorAt(filename, valNode, "%s %v", field, err))
+			case v != math.Trunc(v):
+				errs = append(errs, errorAt(filename, valNode, "%s '%s' must be a whole number", field, valNode.Value))
+			case section.name == "requests":
+				errs = append(errs, validateRequestEqualsLimit(valNode, limits, path, name, filename)...)
+			}
+		}
+	}
+	return errs
+}
+
+// validateResourceNames warns about resource names without a domain that
+// are not standard ones, which are most likely typos.
+func validateResourceNames(contNode *yaml.Node, path, filename string) []Finding {
+	requests, limits := resourceSections(contNode)
+	var errs []Finding
+	for _, section := range []struct {
+		name string
+		node *yaml.Node
+	}{{"requests", requests}, {"limits", limits}} {
+		entries := mapEntries(section.node)
+		for i := 0; i < len(entries); i += 2 {
+			k := entries[i]
+			if k.Kind != yaml.ScalarNode || strings.Contains(k.Value, "/") || standardResources[k.Value] || isHugePages(k.Value) {
+				continue
+			}
+			field := joinPath(path+".resources."+section.name, k.Value)
+			if s := closestName(k.Value, standardResources); s != "" {
+				errs = append(errs, warningAt(filename, k, "%s is not a known resource (did you mean '%s'?)", field, s))
+			} else {
+				errs = append(errs, warningAt(filename, k, "%s is not a known resource; extended resources are named with a domain, such as example.com/%s", field, k.Value))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed ruledocs.yaml
+var ruleDocsYAML []byte
+
+// Categories lists the rule categories in the order they are presented.
+var Categories = []string{"input", "objects", "workloads", "services", "pods", "containers", "policy", "security"}
+
+// RuleOption is a setting a rule reads from its entry in the configuration
+// file.
+type RuleOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ruleDoc is an entry of ruledocs.yaml.
+type ruleDoc struct {
+	Category  string            `yaml:"category"`
+	Details   string            `yaml:"details"`
+	Rationale string            `yaml:"rationale"`
+	Bad       string            `yaml:"bad"`
+	Good      string            `yaml:"good"`
+	Options   map[string]string `yaml:"options"`
+}
+
+// The documentation is attached to the registry when the package loads, so
+// Rules returns it with every rule.
+func init() {
+	var docs map[string]ruleDoc
+	if err := yaml.Unmarshal(ruleDocsYAML, &docs); err != nil {
+		panic(fmt.Sprintf("ruledocs.yaml: %v", err))
+	}
+	for i := range rules {
+		doc, ok := docs[rules[i].ID]
+		if !ok {
+			continue
+		}
+		r := &rules[i]
+		r.Category, r.Details, r.Rationale, r.Bad, r.Good = doc.Category, doc.Details, doc.Rationale, doc.Bad, doc.Good
+		for name, desc := range doc.Options {
+			r.Options = append(r.Options, RuleOption{Name: name, Description: desc})
+		}
+		sort.Slice(r.Options, func(a, b int) bool { return r.Options[a].Name < r.Options[b].Name })
+	}
+}
@@ -0,0 +1,316 @@
+package validator
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a named check. Every finding carries the ID of the rule that
+// produced it.
+type Rule struct {
+	ID          string
+	Description string
+	// Severity is the severity of the rule's findings unless the check
+	// states otherwise.
+	Severity Severity
+	// Preset, if set, names the preset the rule belongs to. Such rules only
+	// run when their preset is turned on or they are enabled by name.
+	Preset string
+
+	// Category groups the rule with related ones. Details and Rationale
+	// say what the rule checks and why it matters, Bad and Good are
+	// examples of YAML failing and passing it, and Options lists what it
+	// reads from the configuration file. They come from ruledocs.yaml.
+	Category           string
+	Details, Rationale string
+	Bad, Good          string
+	Options            []RuleOption
+
+	// At most one of the checks is set. Pod and container checks only run
+	// for kinds that embed a pod spec, and related checks run by
+	// CheckRelations once the objects of every stream are known. Rules
+	// without a check are applied by validateDocument itself.
+	check          func(d *document) []Finding
+	checkPod       func(specNode *yaml.Node, specPath, filename string) []Finding
+	checkContainer func(d *document, c container) []Finding
+	checkRelated   func(obj *Object, objs *objectIndex) []Finding
+
+	// fix, if set, rewrites in place the values reported by the rule that
+	// have one obvious correction, such as the casing of an enum, and
+	// returns how many it changed. It runs on the document before aliases
+	// are expanded, so anchored values are fixed where they are defined.
+	fix func(d *document) int
+}
+
+// document is a parsed object together with what the rules need to know
+// about it.
+type document struct {
+	filename string
+	cfg      *Config
+	root     *yaml.Node
+	mapping  *yaml.Node
+	kind     string
+	// copies holds the nodes created by expanding aliases.
+	copies map[*yaml.Node]bool
+	// spec is the pod spec, or nil if the kind has none or it is malformed.
+	spec     *yaml.Node
+	specPath string
+	specErrs []Finding
+	// resources is the parsed resources of spec, set by podResources.
+	resources *podResources
+}
+
+// container is one entry of containers or initContainers.
+type container struct {
+	node *yaml.Node
+	path string
+	init bool
+}
+
+// rules is the registry of every check, in the order they run.
+var rules = []Rule{
+	{ID: "invisible-characters", Description: "Files should not hold indenting tabs, non-breaking or zero-width spaces, or stray carriage returns", Severity: SeverityWarning},
+	{ID: "duplicate-key", Description: "Mapping keys must be unique", Severity: SeverityError,
+		check: func(d *document) []Finding { return validateDuplicateKeys(d.root, d.copies, d.filename) }},
+	{ID: "unknown-field", Description: "Objects must only set known fields (with --strict-fields)", Severity: SeverityError,
+		check: validateUnknownFields},
+	{ID: "schema", Description: "Objects must match the OpenAPI schema of their kind (with --schema-dir or --schema-from-cluster)", Severity: SeverityError,
+		check: validateSchema},
+	{ID: "empty-document", Description: "Documents should not be empty", Severity: SeverityWarning,
+		check: validateEmptyDocument},
+	{ID: "object-header", Description: "apiVersion, kind and metadata.name must be set and valid", Severity: SeverityError,
+		check: func(d *document) []Finding { return validateObjectHeader(d.mapping, d.filename) }},
+	{ID: "list-items", Description: "A List must hold a list of objects under items", Severity: SeverityError,
+		check: validateListItems},
+	{ID: "ambiguous-scalar", Description: "Unquoted strings should not read as booleans or numbers to YAML 1.1 parsers", Severity: SeverityWarning,
+		check: validateAmbiguousScalars},
+	{ID: "string-value", Description: "Env, label, annotation and ConfigMap and Secret data values must be strings", Severity: SeverityError,
+		check: validateStringValues, fix: fixStringValues},
+	{ID: "kind", Description: "kind should not look like a misspelled built-in kind", Severity: SeverityWarning,
+		check: validateKind},
+	{ID: "deprecated-api", Description: "apiVersion must still serve the kind in the target Kubernetes version", Severity: SeverityError,
+		check: validateDeprecatedAPI},
+	{ID: "labels", Description: "Label and annotation keys and label values must be valid", Severity: SeverityError,
+		check: validateObjectLabels},
+	{ID: "replicas", Description: "spec.replicas must be a non-negative integer", Severity: SeverityError,
+		check: validateReplicas},
+	{ID: "selector", Description: "Workload selectors must be well formed and match the pod template labels", Severity: SeverityError,
+		check: validateSelector},
+	{ID: "cronjob", Description: "CronJob schedule, concurrencyPolicy and history limits must be valid", Severity: SeverityError,
+		check: validateCronJob},
+	{ID: "config-data", Description: "ConfigMap and Secret keys, encoded values and Secret types must be valid", Severity: SeverityError,
+		check: validateConfigData},
+	{ID: "service", Description: "Service type, ports and externalName must be valid", Severity: SeverityError,
+		check: validateService, fix: fixServiceProtocols},
+	{ID: "ingress", Description: "Ingress paths, backends, hosts and TLS hosts must be valid", Severity: SeverityError,
+		check: validateIngress},
+	{ID: "duplicate-object", Description: "Objects of a run must not share a kind, namespace and name", Severity: SeverityError,
+		checkRelated: validateDuplicateObjects},
+	{ID: "hpa", Description: "HorizontalPodAutoscaler replica bounds, scaleTargetRef and metrics must be valid", Severity: SeverityError,
+		check: validateHPA},
+	{ID: "hpa-target", Description: "HorizontalPodAutoscalers must scale a workload of the run that leaves spec.replicas unset", Severity: SeverityError,
+		checkRelated: validateHPATarget},
+	{ID: "pod-spec", Description: "Workloads must contain a pod spec with at least one container", Severity: SeverityError,
+		check: func(d *document) []Finding { return d.specErrs }},
+
+	{ID: "os-value", Description: "spec.os must name an allowed OS, linux or windows by default", Severity: SeverityError,
+		check: func(d *document) []Finding {
+			if d.spec == nil {
+				return nil
+			}
+			allowed := d.cfg.rule("os-value").Allowed
+			if len(allowed) == 0 {
+				allowed = defaultOSNames
+			}
+			return validateOS(d.spec, d.specPath, allowed, d.filename)
+		},
+		fix: fixOS},
+	{ID: "os-fields", Description: "Linux-only fields must not be set on Windows pods", Severity: SeverityError,
+		checkPod: validateOSFields},
+	{ID: "restart-policy", Description: "spec.restartPolicy must be Always, OnFailure or Never", Severity: SeverityError,
+		checkPod: validateRestartPolicy},
+	{ID: "dns-policy", Description: "spec.dnsPolicy must be a supported policy", Severity: SeverityError,
+		checkPod: validateDNSPolicy},
+	{ID: "host-namespaces", Description: "hostNetwork, hostPID and hostIPC must be booleans and are flagged when enabled", Severity: SeverityError,
+		checkPod: validateHostNamespaces, fix: fixHostNamespaces},
+	{ID: "pod-integers", Description: "Pod-level integer fields must be in range", Severity: SeverityError,
+		checkPod: validatePodIntegers},
+	{ID: "host-aliases", Description: "spec.hostAliases must hold valid IPs and hostnames", Severity: SeverityError,
+		checkPod: validateHostAliases},
+	{ID: "pod-references", Description: "serviceAccountName, priorityClassName and imagePullSecrets must name valid objects", Severity: SeverityError,
+		checkPod: validatePodReferences},
+	{ID: "service-account-field", Description: "spec.serviceAccount is deprecated in favor of serviceAccountName", Severity: SeverityWarning,
+		checkPod: validateServiceAccountField},
+	{ID: "tolerations", Description: "Tolerations must use valid operators, effects and seconds", Severity: SeverityError,
+		checkPod: validateTolerations},
+	{ID: "node-selector", Description: "spec.nodeSelector must hold valid labels", Severity: SeverityError,
+		checkPod: validateNodeSelector},
+	{ID: "node-affinity", Description: "Node affinity terms must be satisfiable and well formed", Severity: SeverityError,
+		checkPod: validateNodeAffinity},
+	{ID: "topology-spread", Description: "Topology spread constraints must be complete and unique", Severity: SeverityError,
+		checkPod: validateTopologySpreadConstraints},
+	{ID: "security-context", Description: "Security contexts must be valid and consistent", Severity: SeverityError,
+		checkPod: validateSecurityContexts, fix: fixRunAsNonRoot},
+	{ID: "container-names", Description: "Container names must be valid and unique", Severity: SeverityError,
+		checkPod: validateContainerNames},
+	{ID: "container-ports", Description: "Container ports must be in range and unique", Severity: SeverityError,
+		checkPod: validatePorts, fix: fixContainerProtocols},
+	{ID: "volumes", Description: "Volumes must have valid names and exactly one source", Severity: SeverityError,
+		checkPod: validateVolumes},
+	{ID: "volume-mounts", Description: "Volume mounts must reference declared volumes", Severity: SeverityError,
+		checkPod: validateVolumeMounts},
+
+	{ID: "image-tag", Description: "Images should be pinned to a tag other than latest or a digest", Severity: SeverityWarning,
+		checkContainer: func(d *document, c container) []Finding {
+			return validateImage(c.node, c.path, d.cfg.rule("image-tag").AllowLatest, d.filename)
+		}},
+	{ID: "container-restart-policy", Description: "Only sidecar init containers may set restartPolicy", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			return validateContainerRestartPolicy(c.node, c.path, c.init, d.filename)
+		}},
+	{ID: "command-args", Description: "command and args must be lists of strings", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateCommandArgs(c.node, c.path, d.filename) }},
+	{ID: "image-pull-policy", Description: "imagePullPolicy must be Always, IfNotPresent or Never", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateImagePullPolicy(c.node, c.path, d.filename) },
+		fix:            fixImagePullPolicy},
+	{ID: "env", Description: "Environment variables must have valid names and one value source", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateEnv(c.node, c.path, d.filename) }},
+	{ID: "env-from", Description: "envFrom entries must reference a ConfigMap or Secret", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateEnvFrom(c.node, c.path, d.filename) }},
+	{ID: "probes", Description: "Probes must have one handler, valid ports and valid timings", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateProbes(c.node, c.path, d.filename) }},
+	{ID: "lifecycle", Description: "Lifecycle hooks must have exactly one handler", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateLifecycle(c.node, c.path, d.filename) }},
+	{ID: "init-container-probes", Description: "Only sidecar init containers may define probes", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			if !c.init {
+				return nil
+			}
+			return validateInitContainerProbes(c.node, c.path, d.filename)
+		}},
+	{ID: "cpu-quantity", Description: "CPU requests and limits must be valid quantities", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			errs := validateCPU(c.node, c.path, d.filename)
+			if d.cfg.rule("cpu-quantity").Millicores {
+				errs = append(errs, validateMillicores(c.node, c.path, d.filename)...)
+			}
+			return errs
+		},
+		fix: fixMillicores},
+	{ID: "memory-quantity", Description: "Memory requests and limits must be valid quantities", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateMemory(c.node, c.path, d.filename) }},
+	{ID: "ephemeral-storage-quantity", Description: "ephemeral-storage requests and limits must be valid quantities", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			return validateResourceQuantity(c.node, c.path, d.filename, "ephemeral-storage", memorySuffixes)
+		}},
+	{ID: "empty-dir-size", Description: "emptyDir sizeLimit should fit the limits of the containers using it", Severity: SeverityWarning,
+		check: validateEmptyDirSize},
+	{ID: "requests-within-limits", Description: "Resource requests must not exceed limits", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			return validateRequestsWithinLimits(c.node, c.path, d.filename)
+		}},
+	{ID: "hugepages", Description: "hugepages requests must equal limits and come with cpu or memory", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateHugePages(c.node, c.path, d.filename) }},
+	{ID: "extended-resources", Description: "Extended resources must be whole numbers with requests equal to limits", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding { return validateExtendedResources(c.node, c.path, d.filename) }},
+	{ID: "resource-name", Description: "Resource names without a domain should be standard ones", Severity: SeverityWarning,
+		checkContainer: func(d *document, c container) []Finding { return validateResourceNames(c.node, c.path, d.filename) }},
+	{ID: "working-dir", Description: "workingDir must be an absolute path for the pod's OS", Severity: SeverityError,
+		checkContainer: func(d *document, c container) []Finding {
+			return validateWorkingDir(c.node, c.path, podOSName(d.spec), d.filename)
+		}},
+	{ID: "resource-requirements", Description: "Containers should set cpu and memory requests and limits", Severity: SeverityWarning,
+		checkContainer: validateResourceRequirements},
+	{ID: "readiness-probe", Description: "Containers should define a readinessProbe", Severity: SeverityWarning,
+		checkContainer: validateReadinessProbe},
+	{ID: "identical-probes", Description: "livenessProbe should differ from readinessProbe", Severity: SeverityWarning,
+		checkContainer: validateIdenticalProbes},
+
+	{ID: "service-selector-target", Description: "Service selectors should match the pods of a workload of the run (with --check-references)", Severity: SeverityWarning, Preset: "references",
+		checkRelated: validateServiceSelectorTargets},
+	{ID: "object-reference", Description: "Pods should refer to ConfigMaps, Secrets and claims defined in the run (with --check-references)", Severity: SeverityWarning, Preset: "references",
+		checkRelated: validateObjectRefs},
+	{ID: "run-as-non-root", Description: "Containers should set runAsNonRoot (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkContainer: validateRunAsNonRoot},
+	{ID: "read-only-root-filesystem", Description: "Containers should set readOnlyRootFilesystem (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkContainer: validateReadOnlyRootFilesystem},
+	{ID: "drop-all-capabilities", Description: "Containers should drop ALL capabilities (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkContainer: validateDropAllCapabilities},
+	{ID: "no-host-path", Description: "Pods should not mount hostPath volumes (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkPod: validateNoHostPath},
+	{ID: "no-host-namespaces", Description: "Pods should not share the host's network, PID or IPC namespace (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkPod: validateNoHostNamespaces},
+	{ID: "automount-service-account-token", Description: "Pods should not mount a service account token by default (with --preset security)", Severity: SeverityWarning, Preset: "security",
+		checkPod: validateAutomountServiceAccountToken},
+
+	{ID: "unused-ignore", Description: "lint-ignore comments must silence a finding (with --report-unused-ignores)", Severity: SeverityWarning},
+}
+
+// Rules returns the registered rules in the order they run.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// tagFindings tags the findings of r with its ID and any severity cfg sets
+// for it.
+func tagFindings(cfg *Config, r Rule, fs []Finding) []Finding {
+	severity := cfg.rule(r.ID).Severity
+	for i := range fs {
+		fs[i].RuleID = r.ID
+		if severity != "" {
+			fs[i].Severity = severity
+		}
+	}
+	return fs
+}
+
+// lookupRule returns the registered rule with the given ID.
+func lookupRule(id string) Rule {
+	for _, r := range rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	panic("unknown rule " + id)
+}
+
+// runRule runs the rule with the given ID against d, if it is enabled, and
+// tags its findings.
+func runRule(d *document, id string) []Finding {
+	if r := lookupRule(id); d.cfg.enabled(r) {
+		return tagFindings(d.cfg, r, r.check(d))
+	}
+	return nil
+}
+
+// runRules runs every enabled rule against d and tags the findings with
+// the rule ID and any configured severity. Container rules run container
+// by container.
+func runRules(d *document) []Finding {
+	var findings []Finding
+	tag := func(r Rule, fs []Finding) {
+		findings = append(findings, tagFindings(d.cfg, r, fs)...)
+	}
+	for _, r := range rules {
+		if !d.cfg.enabled(r) {
+			continue
+		}
+		switch {
+		case r.check != nil:
+			tag(r, r.check(d))
+		case r.checkPod != nil && d.spec != nil:
+			tag(r, r.checkPod(d.spec, d.specPath, d.filename))
+		}
+	}
+	if d.spec == nil {
+		return findings
+	}
+	forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+		c := container{node: contNode, path: path, init: init}
+		for _, r := range rules {
+			if r.checkContainer != nil && d.cfg.enabled(r) {
+				tag(r, r.checkContainer(d, c))
+			}
+		}
+	})
+	return findings
+}
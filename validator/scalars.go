@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yaml11Bools are the plain scalars that YAML 1.1 parsers, such as the one
+// behind kubectl and the API server's YAML support, read as booleans
+// although yaml.v3 keeps them as strings.
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+var (
+	// leadingZeroRe matches integers written with a leading zero, such as
+	// file modes like 0755.
+	leadingZeroRe = regexp.MustCompile(`^[-+]?0[0-9_]+$`)
+	// sexagesimalRe matches the base-60 numbers of YAML 1.1, such as 1:30.
+	sexagesimalRe = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+)
+
+// yaml11Reading returns how YAML 1.1 parsers, unlike yaml.v3, read a plain
+// scalar that yaml.v3 reads as a string, such as "the boolean false", or ""
+// if they read it as written too.
+func yaml11Reading(node *yaml.Node) string {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 || node.Tag != "!!str" {
+		return ""
+	}
+	if b, ok := yaml11Bools[node.Value]; ok {
+		return fmt.Sprintf("the boolean %t", b)
+	}
+	if sexagesimalRe.MatchString(node.Value) {
+		return "the base-60 number " + sexagesimal(node.Value)
+	}
+	return ""
+}
+
+// typedReading returns how a plain scalar that YAML reads as a boolean or a
+// number is read, such as "the octal number 493" for 0755, or "" if it is
+// read as a string.
+func typedReading(node *yaml.Node) string {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 {
+		return ""
+	}
+	switch node.Tag {
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err == nil {
+			return fmt.Sprintf("the boolean %t", b)
+		}
+	case "!!int", "!!float":
+		var n float64
+		if err := node.Decode(&n); err != nil {
+			return ""
+		}
+		if leadingZeroRe.MatchString(node.Value) && node.Tag == "!!int" {
+			return "the octal number " + strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return "the number " + strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return ""
+}
+
+// sexagesimal returns the value of a YAML 1.1 base-60 number.
+func sexagesimal(v string) string {
+	neg := strings.HasPrefix(v, "-")
+	var n float64
+	for _, part := range strings.Split(strings.TrimLeft(strings.ReplaceAll(v, "_", ""), "+-"), ":") {
+		f, _ := strconv.ParseFloat(part, 64)
+		n = n*60 + f
+	}
+	if neg {
+		n = -n
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// forEachStringValue calls fn with every value of the object that the API
+// only accepts as a string: label and annotation values, env values, and the
+// ConfigMap data and Secret stringData values.
+func forEachStringValue(d *document, fn func(node *yaml.Node, field string)) {
+	for _, m := range objectMetadata(d) {
+		for _, key := range []string{"labels", "annotations"} {
+			entries := mapEntries(findMapKey(m.node, key))
+			for i := 0; i < len(entries); i += 2 {
+				fn(entries[i+1], joinPath(m.path+"."+key, entries[i].Value))
+			}
+		}
+	}
+	var dataSection string
+	switch d.kind {
+	case "ConfigMap":
+		dataSection = "data"
+	case "Secret":
+		dataSection = "stringData"
+	}
+	if dataSection != "" {
+		entries := mapEntries(findMapKey(d.mapping, dataSection))
+		for i := 0; i < len(entries); i += 2 {
+			fn(entries[i+1], joinPath(dataSection, entries[i].Value))
+		}
+	}
+	if d.spec == nil {
+		return
+	}
+	forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+		envNode := findMapKey(contNode, "env")
+		if envNode == nil || envNode.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, entry := range envNode.Content {
+			if valNode := findMapKey(entry, "value"); valNode != nil {
+				fn(valNode, fmt.Sprintf("%s.env[%d].value", path, i))
+			}
+		}
+	})
+}
+
+// validateAmbiguousScalars warns about unquoted strings that YAML 1.1
+// parsers, such as kubectl's, read as booleans or numbers, in the string
+// values of an object and in images.
+func validateAmbiguousScalars(d *document) []Finding {
+	var errs []Finding
+	check := func(node *yaml.Node, field string) {
+		if reading := yaml11Reading(node); reading != "" {
+			errs = append(errs, warningAt(d.filename, node, "%s is the unquoted %s, which YAML 1.1 parsers such as kubectl's read as %s; quote it as \"%s\"", field, node.Value, reading, node.Value))
+		}
+	}
+	forEachStringValue(d, check)
+	if d.spec != nil {
+		forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+			if imageNode := findMapKey(contNode, "image"); imageNode != nil {
+				check(imageNode, path+".image")
+			}
+		})
+	}
+	return errs
+}
+
+// validateStringValues reports the string values of an object, such as env
+// values, written as unquoted booleans or numbers, which the API rejects.
+func validateStringValues(d *document) []Finding {
+	var errs []Finding
+	forEachStringValue(d, func(node *yaml.Node, field string) {
+		if reading := typedReading(node); reading != "" {
+			errs = append(errs, errorAt(d.filename, node, "%s must be a string, but the unquoted %s is read as %s; quote it as \"%s\"", field, node.Value, reading, node.Value))
+		}
+	})
+	return errs
+}
+
+// fixStringValues quotes the values reported by string-value, keeping the
+// text as written, so 0755 becomes "0755" rather than "493".
+func fixStringValues(d *document) int {
+	fixed := 0
+	forEachStringValue(d, func(node *yaml.Node, field string) {
+		if typedReading(node) != "" {
+			node.Tag, node.Style = "!!str", yaml.DoubleQuotedStyle
+			fixed++
+		}
+	})
+	return fixed
+}
@@ -0,0 +1,199 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	tolerationOperators = []string{"Equal", "Exists"}
+	taintEffects        = []string{"", "NoSchedule", "PreferNoSchedule", "NoExecute"}
+)
+
+func validateTolerations(specNode *yaml.Node, specPath, filename string) []Finding {
+	tolsNode := findMapKey(specNode, "tolerations")
+	if tolsNode == nil || tolsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	for i, tol := range tolsNode.Content {
+		if tol.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.tolerations[%d]", specPath, i)
+		operator := "Equal"
+		opNode := findMapKey(tol, "operator")
+		if opNode != nil {
+			errs = append(errs, validateEnum(opNode, field+".operator", tolerationOperators, filename)...)
+			operator = opNode.Value
+		}
+		effect := ""
+		if effectNode := findMapKey(tol, "effect"); effectNode != nil {
+			errs = append(errs, validateEnum(effectNode, field+".effect", taintEffects, filename)...)
+			effect = effectNode.Value
+		}
+		if secsNode := findMapKey(tol, "tolerationSeconds"); secsNode != nil {
+			if v, err := strconv.Atoi(secsNode.Value); secsNode.Kind != yaml.ScalarNode || err != nil || v < 0 {
+				errs = append(errs, errorAt(filename, secsNode, "%s.tolerationSeconds must be a non-negative integer", field))
+			} else if effect != "NoExecute" {
+				errs = append(errs, errorAt(filename, secsNode, "%s.tolerationSeconds only applies to effect NoExecute", field))
+			}
+		}
+		if valueNode := findMapKey(tol, "value"); valueNode != nil && operator == "Exists" && valueNode.Value != "" {
+			errs = append(errs, errorAt(filename, valueNode, "%s.value must be empty when operator is Exists", field))
+		}
+		if keyNode := findMapKey(tol, "key"); (keyNode == nil || keyNode.Value == "") && operator != "Exists" {
+			at := tol
+			if opNode != nil {
+				at = opNode
+			}
+			errs = append(errs, errorAt(filename, at, "%s.operator must be Exists when key is empty", field))
+		}
+	}
+	return errs
+}
+
+func validateNodeSelector(specNode *yaml.Node, specPath, filename string) []Finding {
+	return validateLabelMap(findMapKey(specNode, "nodeSelector"), specPath+".nodeSelector", true, filename)
+}
+
+var nodeSelectorOperators = []string{"In", "NotIn", "Exists", "DoesNotExist", "Gt", "Lt"}
+
+// validateNodeAffinity checks the node selector terms of
+// spec.affinity.nodeAffinity, both required and preferred.
+func validateNodeAffinity(specNode *yaml.Node, specPath, filename string) []Finding {
+	naNode := findMapKey(findMapKey(specNode, "affinity"), "nodeAffinity")
+	if naNode == nil || naNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	field := specPath + ".affinity.nodeAffinity"
+	if reqNode := findMapKey(naNode, "requiredDuringSchedulingIgnoredDuringExecution"); reqNode != nil {
+		reqField := field + ".requiredDuringSchedulingIgnoredDuringExecution"
+		termsNode := findMapKey(reqNode, "nodeSelectorTerms")
+		if termsNode == nil || termsNode.Kind != yaml.SequenceNode || len(termsNode.Content) == 0 {
+			// No term can ever match, so the pod would never be scheduled
+			errs = append(errs, errorAt(filename, reqNode, "%s.nodeSelectorTerms must not be empty", reqField))
+		} else {
+			for i, term := range termsNode.Content {
+				errs = append(errs, validateNodeSelectorTerm(term, fmt.Sprintf("%s.nodeSelectorTerms[%d]", reqField, i), filename)...)
+			}
+		}
+	}
+	if prefNode := findMapKey(naNode, "preferredDuringSchedulingIgnoredDuringExecution"); prefNode != nil && prefNode.Kind == yaml.SequenceNode {
+		for i, pref := range prefNode.Content {
+			prefField := fmt.Sprintf("%s.preferredDuringSchedulingIgnoredDuringExecution[%d].preference", field, i)
+			errs = append(errs, validateNodeSelectorTerm(findMapKey(pref, "preference"), prefField, filename)...)
+		}
+	}
+	return errs
+}
+
+func validateNodeSelectorTerm(term *yaml.Node, field, filename string) []Finding {
+	if term == nil || term.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	for _, key := range []string{"matchExpressions", "matchFields"} {
+		expsNode := findMapKey(term, key)
+		if expsNode == nil || expsNode.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, exp := range expsNode.Content {
+			errs = append(errs, validateSelectorRequirement(exp, fmt.Sprintf("%s.%s[%d]", field, key, i), nodeSelectorOperators, filename)...)
+		}
+	}
+	return errs
+}
+
+// validateSelectorRequirement checks one key/operator/values requirement of
+// a node or label selector against the operators the selector supports.
+func validateSelectorRequirement(exp *yaml.Node, field string, operators []string, filename string) []Finding {
+	if exp.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	if keyNode, missing := requireKey(filename, exp, field, "key"); keyNode == nil {
+		errs = append(errs, missing)
+	} else if keyNode.Value == "" {
+		errs = append(errs, errorAt(filename, keyNode, "%s.key is required", field))
+	}
+	opNode, missing := requireKey(filename, exp, field, "operator")
+	if opNode == nil {
+		return append(errs, missing)
+	}
+	if opErrs := validateEnum(opNode, field+".operator", operators, filename); len(opErrs) > 0 {
+		return append(errs, opErrs...)
+	}
+	valuesNode := findMapKey(exp, "values")
+	count := 0
+	if valuesNode != nil && valuesNode.Kind == yaml.SequenceNode {
+		count = len(valuesNode.Content)
+	}
+	switch opNode.Value {
+	case "In", "NotIn":
+		if count == 0 {
+			errs = append(errs, errorAt(filename, opNode, "%s.values must not be empty for operator %s", field, opNode.Value))
+		}
+	case "Exists", "DoesNotExist":
+		if valuesNode != nil {
+			errs = append(errs, errorAt(filename, valuesNode, "%s.values must not be set for operator %s", field, opNode.Value))
+		}
+	case "Gt", "Lt":
+		if count != 1 {
+			errs = append(errs, errorAt(filename, opNode, "%s.values must have exactly one element for operator %s", field, opNode.Value))
+		} else if _, err := strconv.ParseInt(valuesNode.Content[0].Value, 10, 64); err != nil {
+			errs = append(errs, errorAt(filename, valuesNode.Content[0], "%s.values must be an integer for operator %s", field, opNode.Value))
+		}
+	}
+	return errs
+}
+
+var unsatisfiableActions = []string{"DoNotSchedule", "ScheduleAnyway"}
+
+func validateTopologySpreadConstraints(specNode *yaml.Node, specPath, filename string) []Finding {
+	tscNode := findMapKey(specNode, "topologySpreadConstraints")
+	if tscNode == nil || tscNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	seen := map[string]bool{}
+	for i, c := range tscNode.Content {
+		if c.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.topologySpreadConstraints[%d]", specPath, i)
+		if skewNode, missing := requireKey(filename, c, field, "maxSkew"); skewNode == nil {
+			errs = append(errs, missing)
+		} else if v, err := strconv.Atoi(skewNode.Value); skewNode.Kind != yaml.ScalarNode || err != nil || v < 1 {
+			errs = append(errs, errorAt(filename, skewNode, "%s.maxSkew must be an integer of at least 1", field))
+		}
+		keyNode, missing := requireKey(filename, c, field, "topologyKey")
+		if keyNode == nil {
+			errs = append(errs, missing)
+		} else if keyNode.Value == "" {
+			errs = append(errs, errorAt(filename, keyNode, "%s.topologyKey is required", field))
+		} else if !isQualifiedName(keyNode.Value) {
+			errs = append(errs, errorAt(filename, keyNode, "%s.topologyKey '%s' is not a valid label key", field, keyNode.Value))
+		}
+		whenNode, missing := requireKey(filename, c, field, "whenUnsatisfiable")
+		if whenNode == nil {
+			errs = append(errs, missing)
+		} else {
+			errs = append(errs, validateEnum(whenNode, field+".whenUnsatisfiable", unsatisfiableActions, filename)...)
+		}
+		if selNode := findMapKey(c, "labelSelector"); selNode != nil && selNode.Kind != yaml.MappingNode {
+			errs = append(errs, errorAt(filename, selNode, "%s.labelSelector must be a mapping", field))
+		}
+		if keyNode != nil && whenNode != nil {
+			key := keyNode.Value + "/" + whenNode.Value
+			if seen[key] {
+				errs = append(errs, errorAt(filename, keyNode, "%s duplicates topologyKey '%s' with whenUnsatisfiable %s", field, keyNode.Value, whenNode.Value))
+			}
+			seen[key] = true
+		}
+	}
+	return errs
+}
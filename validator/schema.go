@@ -0,0 +1,270 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema holds the OpenAPI v3 definitions of the API types, as served by
+// a cluster under /openapi/v3, indexed by the kinds they describe.
+type Schema struct {
+	defs map[string]*schemaNode
+	// byKind maps apiVersion/kind, as in apps/v1/Deployment, to the name
+	// of the definition of that kind.
+	byKind map[string]string
+}
+
+// schemaNode is the part of an OpenAPI schema object the schema rule
+// understands.
+type schemaNode struct {
+	Ref         string                 `json:"$ref"`
+	Type        string                 `json:"type"`
+	Format      string                 `json:"format"`
+	Properties  map[string]*schemaNode `json:"properties"`
+	Items       *schemaNode            `json:"items"`
+	Required    []string               `json:"required"`
+	Enum        []any                  `json:"enum"`
+	AllOf       []*schemaNode          `json:"allOf"`
+	OneOf       []*schemaNode          `json:"oneOf"`
+	AnyOf       []*schemaNode          `json:"anyOf"`
+	IntOrString bool                   `json:"x-kubernetes-int-or-string"`
+	// PreserveUnknown lets an object hold fields its schema does not list.
+	PreserveUnknown bool `json:"x-kubernetes-preserve-unknown-fields"`
+	// AdditionalProperties is a schema for the values of a map, or the
+	// boolean true or false.
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	GroupVersionKinds    []struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"x-kubernetes-group-version-kind"`
+
+	// values is AdditionalProperties decoded as a schema, and anyKey is
+	// set when any key is allowed.
+	values *schemaNode
+	anyKey bool
+}
+
+// NewSchema returns an empty schema to which documents are added.
+func NewSchema() *Schema {
+	return &Schema{defs: map[string]*schemaNode{}, byKind: map[string]string{}}
+}
+
+// Add adds the definitions of an OpenAPI v3 document, such as the response
+// of /openapi/v3/apis/apps/v1.
+func (s *Schema) Add(data []byte) error {
+	var doc struct {
+		Components struct {
+			Schemas map[string]*schemaNode `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for name, def := range doc.Components.Schemas {
+		if err := def.prepare(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		s.defs[name] = def
+		for _, gvk := range def.GroupVersionKinds {
+			apiVersion := gvk.Version
+			if gvk.Group != "" {
+				apiVersion = gvk.Group + "/" + gvk.Version
+			}
+			s.byKind[apiVersion+"/"+gvk.Kind] = name
+		}
+	}
+	return nil
+}
+
+// prepare decodes the additionalProperties of n and of the schemas below
+// it.
+func (n *schemaNode) prepare() error {
+	if n == nil {
+		return nil
+	}
+	switch raw := strings.TrimSpace(string(n.AdditionalProperties)); raw {
+	case "", "false":
+	case "true":
+		n.anyKey = true
+	default:
+		n.values = &schemaNode{}
+		if err := json.Unmarshal(n.AdditionalProperties, n.values); err != nil {
+			return err
+		}
+		n.anyKey = true
+	}
+	children := []*schemaNode{n.Items, n.values}
+	for _, p := range n.Properties {
+		children = append(children, p)
+	}
+	children = append(append(append(children, n.AllOf...), n.OneOf...), n.AnyOf...)
+	for _, c := range children {
+		if err := c.prepare(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of kinds the schema describes.
+func (s *Schema) Len() int {
+	return len(s.byKind)
+}
+
+// validateSchema checks the object against the schema of its apiVersion
+// and kind, when a schema is configured.
+func validateSchema(d *document) []Finding {
+	if d.cfg == nil || d.cfg.Schema == nil {
+		return nil
+	}
+	s := d.cfg.Schema
+	apiVersionNode := findMapKey(d.mapping, "apiVersion")
+	kindNode := findMapKey(d.mapping, "kind")
+	if apiVersionNode == nil || kindNode == nil || apiVersionNode.Kind != yaml.ScalarNode || kindNode.Kind != yaml.ScalarNode {
+		// object-header reports these
+		return nil
+	}
+	name, ok := s.byKind[apiVersionNode.Value+"/"+kindNode.Value]
+	if !ok {
+		return []Finding{errorAt(d.filename, kindNode, "kind %s of apiVersion %s is not in the schema", kindNode.Value, apiVersionNode.Value)}
+	}
+	var errs []Finding
+	s.check(d.mapping, s.defs[name], shortName(name), "", d.filename, &errs)
+	return errs
+}
+
+// check appends to errs what is wrong with node according to n, whose
+// type is called typeName in messages.
+func (s *Schema) check(node *yaml.Node, n *schemaNode, typeName, path, filename string, errs *[]Finding) {
+	for depth := 0; n != nil && n.Ref != ""; depth++ {
+		name := strings.TrimPrefix(n.Ref, "#/components/schemas/")
+		if depth > 32 {
+			return
+		}
+		typeName, n = shortName(name), s.defs[name]
+	}
+	if n == nil || node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "the object"
+	}
+	for _, sub := range n.AllOf {
+		s.check(node, sub, typeName, path, filename, errs)
+	}
+	if alts := append(append([]*schemaNode{}, n.OneOf...), n.AnyOf...); len(alts) > 0 {
+		matched := false
+		for _, alt := range alts {
+			var altErrs []Finding
+			s.check(node, alt, typeName, path, filename, &altErrs)
+			if len(altErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, errorAt(filename, node, "%s does not match any of the types allowed by %s", field, typeName))
+			return
+		}
+	}
+	if n.IntOrString || n.Format == "int-or-string" {
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" && node.Tag != "!!str" {
+			*errs = append(*errs, errorAt(filename, node, "%s must be an integer or a string", field))
+		}
+		return
+	}
+	if !schemaTypeMatches(node, n.Type, typeName) {
+		*errs = append(*errs, errorAt(filename, node, "%s must be %s", field, schemaTypeName(n.Type)))
+		return
+	}
+	if len(n.Enum) > 0 && node.Kind == yaml.ScalarNode {
+		allowed := make([]string, len(n.Enum))
+		for i, e := range n.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		if !slices.Contains(allowed, node.Value) {
+			*errs = append(*errs, errorAt(filename, node, "%s has unsupported value '%s', want one of: %s", field, node.Value, strings.Join(allowed, ", ")))
+		}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		entries := mapEntries(node)
+		for _, req := range n.Required {
+			if reqNode, missing := requireKey(filename, node, path, req); reqNode == nil {
+				*errs = append(*errs, missing)
+			}
+		}
+		for i := 0; i < len(entries); i += 2 {
+			k, v := entries[i], entries[i+1]
+			p := joinPath(path, k.Value)
+			if prop, ok := n.Properties[k.Value]; ok {
+				s.check(v, prop, typeName, p, filename, errs)
+			} else if n.values != nil {
+				s.check(v, n.values, typeName, p, filename, errs)
+			} else if len(n.Properties) > 0 && !n.anyKey && !n.PreserveUnknown {
+				*errs = append(*errs, errorAt(filename, k, "%s is not a known field of %s", p, typeName))
+			}
+		}
+	case yaml.SequenceNode:
+		if n.Items != nil {
+			for i, item := range node.Content {
+				s.check(item, n.Items, typeName, fmt.Sprintf("%s[%d]", path, i), filename, errs)
+			}
+		}
+	}
+}
+
+// schemaTypeMatches reports whether node is of the OpenAPI type t. The
+// tags are those YAML resolves plain scalars to, so quoted numbers are
+// strings. Quantities are written as either.
+func schemaTypeMatches(node *yaml.Node, t, typeName string) bool {
+	if typeName == "Quantity" && node.Kind == yaml.ScalarNode {
+		return true
+	}
+	switch t {
+	case "":
+		return true
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	}
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch t {
+	case "string":
+		return node.Tag == "!!str" || node.Tag == "!!timestamp" || node.Tag == "!!binary"
+	case "integer":
+		return node.Tag == "!!int"
+	case "number":
+		return node.Tag == "!!int" || node.Tag == "!!float"
+	case "boolean":
+		return node.Tag == "!!bool"
+	}
+	return true
+}
+
+func schemaTypeName(t string) string {
+	switch t {
+	case "object":
+		return "a mapping"
+	case "array":
+		return "a list"
+	case "integer":
+		return "an integer"
+	}
+	return "a " + t
+}
+
+// shortName drops the package path from a definition name, turning
+// io.k8s.api.apps.v1.DeploymentSpec into DeploymentSpec.
+func shortName(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// securityContextIDs lists the securityContext fields holding user or group IDs.
+var securityContextIDs = []string{"runAsUser", "runAsGroup", "fsGroup"}
+
+// validateSecurityContext checks the field types of a pod or container
+// securityContext and the runAsNonRoot/runAsUser combination within it.
+func validateSecurityContext(scNode *yaml.Node, field, filename string) []Finding {
+	var errs []Finding
+	for _, id := range securityContextIDs {
+		idNode := findMapKey(scNode, id)
+		if idNode == nil {
+			continue
+		}
+		if v, err := strconv.Atoi(idNode.Value); idNode.Kind != yaml.ScalarNode || err != nil || v < 0 {
+			errs = append(errs, errorAt(filename, idNode, "%s.%s must be a non-negative integer", field, id))
+		}
+	}
+	nonRoot := findMapKey(scNode, "runAsNonRoot")
+	if nonRoot != nil && nonRoot.Tag != "!!bool" {
+		errs = append(errs, errorAt(filename, nonRoot, "%s.runAsNonRoot must be boolean", field))
+	}
+	if user := findMapKey(scNode, "runAsUser"); isTrue(nonRoot) && user != nil && user.Value == "0" {
+		errs = append(errs, errorAt(filename, user, "%s.runAsNonRoot is true but runAsUser is 0", field))
+	}
+	for _, flag := range []string{"privileged", "allowPrivilegeEscalation"} {
+		if isTrue(findMapKey(scNode, flag)) {
+			errs = append(errs, warningAt(filename, findMapKey(scNode, flag), "%s.%s is enabled", field, flag))
+		}
+	}
+	return errs
+}
+
+// validateSecurityContexts checks the pod securityContext and every container
+// securityContext, including settings that contradict between the two levels.
+func validateSecurityContexts(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	podSC := findMapKey(specNode, "securityContext")
+	if podSC != nil && podSC.Kind == yaml.MappingNode {
+		errs = append(errs, validateSecurityContext(podSC, specPath+".securityContext", filename)...)
+	} else {
+		podSC = nil
+	}
+
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		contSC := findMapKey(contNode, "securityContext")
+		if contSC != nil && contSC.Kind == yaml.MappingNode {
+			errs = append(errs, validateSecurityContext(contSC, path+".securityContext", filename)...)
+			errs = append(errs, validateCapabilities(contSC, path+".securityContext", filename)...)
+		} else {
+			contSC = nil
+		}
+		if podSC == nil {
+			return
+		}
+		// Container settings override pod settings; only report the combination
+		// here when the two halves come from different levels.
+		nonRoot, user := findMapKey(contSC, "runAsNonRoot"), findMapKey(contSC, "runAsUser")
+		if (nonRoot == nil) == (user == nil) {
+			return
+		}
+		if nonRoot == nil {
+			nonRoot = findMapKey(podSC, "runAsNonRoot")
+		} else {
+			user = findMapKey(podSC, "runAsUser")
+		}
+		if isTrue(nonRoot) && user != nil && user.Value == "0" {
+			errs = append(errs, errorAt(filename, contNode, "%s runs as user 0 but runAsNonRoot is true", path))
+		}
+	})
+	return errs
+}
+
+// linuxCapabilities is the set of capability names known to Linux, without
+// the CAP_ prefix, plus ALL.
+var linuxCapabilities = map[string]bool{
+	"ALL": true, "AUDIT_CONTROL": true, "AUDIT_READ": true, "AUDIT_WRITE": true,
+	"BLOCK_SUSPEND": true, "BPF": true, "CHECKPOINT_RESTORE": true, "CHOWN": true,
+	"DAC_OVERRIDE": true, "DAC_READ_SEARCH": true, "FOWNER": true, "FSETID": true,
+	"IPC_LOCK": true, "IPC_OWNER": true, "KILL": true, "LEASE": true,
+	"LINUX_IMMUTABLE": true, "MAC_ADMIN": true, "MAC_OVERRIDE": true, "MKNOD": true,
+	"NET_ADMIN": true, "NET_BIND_SERVICE": true, "NET_BROADCAST": true, "NET_RAW": true,
+	"PERFMON": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true,
+	"SETUID": true, "SYSLOG": true, "SYS_ADMIN": true, "SYS_BOOT": true,
+	"SYS_CHROOT": true, "SYS_MODULE": true, "SYS_NICE": true, "SYS_PACCT": true,
+	"SYS_PTRACE": true, "SYS_RAWIO": true, "SYS_RESOURCE": true, "SYS_TIME": true,
+	"SYS_TTY_CONFIG": true, "WAKE_ALARM": true,
+}
+
+// validateCapabilities checks securityContext.capabilities.add and drop.
+// Unknown names are only warnings as newer kernels keep adding capabilities.
+func validateCapabilities(scNode *yaml.Node, path, filename string) []Finding {
+	capsNode := findMapKey(scNode, "capabilities")
+	if capsNode == nil || capsNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	dropped := map[string]bool{}
+	for _, list := range []string{"drop", "add"} {
+		listNode := findMapKey(capsNode, list)
+		if listNode == nil {
+			continue
+		}
+		field := path + ".capabilities." + list
+		if listNode.Kind != yaml.SequenceNode {
+			errs = append(errs, errorAt(filename, listNode, "%s must be a list", field))
+			continue
+		}
+		for _, item := range listNode.Content {
+			if item.Kind != yaml.ScalarNode {
+				errs = append(errs, errorAt(filename, item, "%s items must be strings", field))
+				continue
+			}
+			name := item.Value
+			upper := strings.ToUpper(name)
+			switch {
+			case name != upper && linuxCapabilities[upper]:
+				errs = append(errs, warningAt(filename, item, "%s has capability '%s' in lowercase (did you mean '%s'?)", field, name, upper))
+			case !linuxCapabilities[name]:
+				errs = append(errs, warningAt(filename, item, "%s has unknown capability '%s'", field, name))
+			}
+			if list == "drop" {
+				dropped[upper] = true
+			} else if dropped[upper] {
+				errs = append(errs, errorAt(filename, item, "%s capability '%s' is both added and dropped", field, name))
+			}
+		}
+	}
+	return errs
+}
+
+// isTrue reports whether node is the boolean scalar true.
+func isTrue(node *yaml.Node) bool {
+	if node == nil || node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+		return false
+	}
+	v, err := strconv.ParseBool(node.Value)
+	return err == nil && v
+}
@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var serviceTypes = []string{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"}
+
+// Default --service-node-port-range of the API server.
+const (
+	minNodePort = 30000
+	maxNodePort = 32767
+)
+
+// validateService checks the type, ports and externalName of a Service.
+func validateService(d *document) []Finding {
+	if d.kind != "Service" {
+		return nil
+	}
+	filename := d.filename
+	specNode := findMapKey(d.mapping, "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []Finding
+	svcType := "ClusterIP"
+	if typeNode := findMapKey(specNode, "type"); typeNode != nil {
+		errs = append(errs, validateEnum(typeNode, "spec.type", serviceTypes, filename)...)
+		svcType = typeNode.Value
+	}
+	portsNode := findMapKey(specNode, "ports")
+	if svcType == "ExternalName" {
+		if nameNode := findMapKey(specNode, "externalName"); nameNode == nil {
+			errs = append(errs, missingAt(filename, specNode, "spec", "externalName", "is required for type ExternalName"))
+		} else if !isDNS1123Subdomain(strings.TrimSuffix(nameNode.Value, ".")) {
+			errs = append(errs, errorAt(filename, nameNode, "spec.externalName '%s' is not a valid DNS name", nameNode.Value))
+		}
+		// An ExternalName Service is only a CNAME; nothing is proxied
+		if selNode := findMapKey(specNode, "selector"); selNode != nil {
+			errs = append(errs, warningAt(filename, selNode, "spec.selector is ignored for type ExternalName"))
+		}
+		if portsNode != nil {
+			errs = append(errs, warningAt(filename, portsNode, "spec.ports is ignored for type ExternalName"))
+		}
+		return errs
+	}
+	if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+		return errs
+	}
+	seenNames := map[string]bool{}
+	for i, portNode := range portsNode.Content {
+		if portNode.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("spec.ports[%d]", i)
+		if pNode, missing := requireKey(filename, portNode, field, "port"); pNode == nil {
+			errs = append(errs, missing)
+		} else {
+			errs = append(errs, validatePortNumber(pNode, field+".port", filename)...)
+		}
+		if tpNode := findMapKey(portNode, "targetPort"); tpNode != nil {
+			errs = append(errs, validateTargetPort(tpNode, field+".targetPort", filename)...)
+		}
+		if protoNode := findMapKey(portNode, "protocol"); protoNode != nil {
+			errs = append(errs, validateEnum(protoNode, field+".protocol", portProtocols, filename)...)
+		}
+		if npNode := findMapKey(portNode, "nodePort"); npNode != nil {
+			errs = append(errs, validateNodePort(npNode, field+".nodePort", svcType, filename)...)
+		}
+		nameNode := findMapKey(portNode, "name")
+		if nameNode == nil {
+			if len(portsNode.Content) > 1 {
+				errs = append(errs, missingAt(filename, portNode, field, "name", "is required when a Service has more than one port"))
+			}
+			continue
+		}
+		if !isDNS1123Label(nameNode.Value) {
+			errs = append(errs, errorAt(filename, nameNode, "%s.name '%s' is not a valid DNS-1123 label", field, nameNode.Value))
+		}
+		if seenNames[nameNode.Value] {
+			errs = append(errs, errorAt(filename, nameNode, "%s.name duplicates port name '%s'", field, nameNode.Value))
+		}
+		seenNames[nameNode.Value] = true
+	}
+	return errs
+}
+
+// validateTargetPort accepts a port number or the name of a container port.
+func validateTargetPort(tpNode *yaml.Node, field, filename string) []Finding {
+	if tpNode.Kind != yaml.ScalarNode {
+		return []Finding{errorAt(filename, tpNode, "%s must be a port number or name", field)}
+	}
+	if tpNode.Tag == "!!int" {
+		return validatePortNumber(tpNode, field, filename)
+	}
+	if !isIANASvcName(tpNode.Value) {
+		return []Finding{errorAt(filename, tpNode, "%s '%s' is not a valid port name", field, tpNode.Value)}
+	}
+	return nil
+}
+
+func validateNodePort(npNode *yaml.Node, field, svcType, filename string) []Finding {
+	if errs := validatePortNumber(npNode, field, filename); len(errs) > 0 {
+		return errs
+	}
+	if svcType == "ClusterIP" {
+		return []Finding{errorAt(filename, npNode, "%s may not be set for type ClusterIP", field)}
+	}
+	// The range is configurable per cluster, so this is only a warning
+	if v, _ := strconv.Atoi(npNode.Value); v < minNodePort || v > maxNodePort {
+		return []Finding{warningAt(filename, npNode, "%s %d is outside the default range %d-%d", field, v, minNodePort, maxNodePort)}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package validator
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// quantity is a parsed resource amount with the node it was read from.
+type quantity struct {
+	value float64
+	node  *yaml.Node
+}
+
+// containerResources holds the parsed requests and limits of a container
+// and the volumes it mounts. Resources whose value doesn't parse are left
+// out.
+type containerResources struct {
+	name             string
+	path             string
+	init             bool
+	requests, limits map[string]quantity
+	mounts           map[string]bool
+}
+
+// emptyDirVolume is an emptyDir of spec.volumes.
+type emptyDirVolume struct {
+	name      string
+	path      string
+	medium    string
+	sizeLimit *quantity
+}
+
+// podResources is the parsed resource view of a pod spec, shared by the
+// checks that compare container resources with volumes.
+type podResources struct {
+	containers []containerResources
+	emptyDirs  []emptyDirVolume
+}
+
+// podResources returns the parsed resources of the pod spec of d, parsing
+// them on first use.
+func (d *document) podResources() *podResources {
+	if d.resources != nil {
+		return d.resources
+	}
+	pr := &podResources{}
+	forEachContainer(d.spec, d.specPath, func(contNode *yaml.Node, path string, init bool) {
+		requests, limits := resourceSections(contNode)
+		cr := containerResources{path: path, init: init, requests: parseQuantities(requests), limits: parseQuantities(limits), mounts: map[string]bool{}}
+		if nameNode := findMapKey(contNode, "name"); nameNode != nil {
+			cr.name = nameNode.Value
+		}
+		if mountsNode := findMapKey(contNode, "volumeMounts"); mountsNode != nil && mountsNode.Kind == yaml.SequenceNode {
+			for _, mount := range mountsNode.Content {
+				if nameNode := findMapKey(mount, "name"); nameNode != nil && nameNode.Kind == yaml.ScalarNode {
+					cr.mounts[nameNode.Value] = true
+				}
+			}
+		}
+		pr.containers = append(pr.containers, cr)
+	})
+	if volsNode := findMapKey(d.spec, "volumes"); volsNode != nil && volsNode.Kind == yaml.SequenceNode {
+		for i, vol := range volsNode.Content {
+			nameNode := findMapKey(vol, "name")
+			srcNode := findMapKey(vol, "emptyDir")
+			if nameNode == nil || srcNode == nil || srcNode.Kind != yaml.MappingNode {
+				continue
+			}
+			ed := emptyDirVolume{name: nameNode.Value, path: joinPath(fmt.Sprintf("%s.volumes[%d]", d.specPath, i), "emptyDir")}
+			if mediumNode := findMapKey(srcNode, "medium"); mediumNode != nil {
+				ed.medium = mediumNode.Value
+			}
+			if sizeNode := findMapKey(srcNode, "sizeLimit"); sizeNode != nil && sizeNode.Kind == yaml.ScalarNode {
+				if v, err := parseQuantity(sizeNode.Value, memorySuffixes); err == nil {
+					ed.sizeLimit = &quantity{v, sizeNode}
+				}
+			}
+			pr.emptyDirs = append(pr.emptyDirs, ed)
+		}
+	}
+	d.resources = pr
+	return pr
+}
+
+// parseQuantities parses the entries of a requests or limits mapping.
+func parseQuantities(section *yaml.Node) map[string]quantity {
+	parsed := map[string]quantity{}
+	entries := mapEntries(section)
+	for i := 0; i < len(entries); i += 2 {
+		name, valNode := entries[i].Value, entries[i+1]
+		if valNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if v, err := parseQuantity(valNode.Value, resourceSuffixes(name)); err == nil {
+			parsed[name] = quantity{v, valNode}
+		}
+	}
+	return parsed
+}
+
+// validateEmptyDirSize warns about an emptyDir whose sizeLimit exceeds
+// what the containers using it may consume: the ephemeral-storage limit of
+// a container that mounts a disk-backed one, or the memory limit of the
+// pod for one backed by memory, which counts against it.
+func validateEmptyDirSize(d *document) []Finding {
+	if d.spec == nil {
+		return nil
+	}
+	pr := d.podResources()
+	var errs []Finding
+	for _, ed := range pr.emptyDirs {
+		if ed.sizeLimit == nil {
+			continue
+		}
+		if ed.medium == "Memory" {
+			if limit, ok := pr.podMemoryLimit(); ok && ed.sizeLimit.value > limit {
+				errs = append(errs, warningAt(d.filename, ed.sizeLimit.node, "%s.sizeLimit (%s) is larger than the memory limit of the pod; a memory-backed emptyDir counts against it, so filling the volume gets containers OOM-killed", ed.path, ed.sizeLimit.node.Value))
+			}
+			continue
+		}
+		for _, c := range pr.containers {
+			limit, ok := c.limits["ephemeral-storage"]
+			if !c.mounts[ed.name] || !ok || ed.sizeLimit.value <= limit.value {
+				continue
+			}
+			errs = append(errs, warningAt(d.filename, ed.sizeLimit.node, "%s.sizeLimit (%s) is larger than the ephemeral-storage limit (%s) of %s, which mounts it; the kubelet may evict the pod before the volume is full", ed.path, ed.sizeLimit.node.Value, limit.node.Value, c.path))
+		}
+	}
+	return errs
+}
+
+// podMemoryLimit returns the memory limit of a pod: the larger of the sum
+// of the limits of its regular containers and the largest limit of an init
+// container. It reports false when a regular container has no limit.
+func (pr *podResources) podMemoryLimit() (float64, bool) {
+	var sum, initMax float64
+	regular := false
+	for _, c := range pr.containers {
+		limit, ok := c.limits["memory"]
+		switch {
+		case c.init:
+			initMax = max(initMax, limit.value)
+		case !ok:
+			return 0, false
+		default:
+			sum += limit.value
+			regular = true
+		}
+	}
+	return max(sum, initMax), regular
+}
@@ -0,0 +1,271 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a difference between two manifests found by Diff.
+type Change struct {
+	// Object names the object the change is in, as in Deployment
+	// default/web, when either stream holds more than one document.
+	Object string `json:"object,omitempty"`
+	// Path is the field path of the change, and empty when a whole object
+	// is added or removed. Items of lists matched by name carry their
+	// index in the new list, or in the old one when removed.
+	Path string `json:"path"`
+	// Type is added, removed or changed.
+	Type string `json:"type"`
+	// Old and New are the values before and after, scalars as they are
+	// and mappings and sequences in flow style.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// diffObject is a document of a stream compared by Diff.
+type diffObject struct {
+	name string
+	node *yaml.Node
+}
+
+// Diff compares two YAML or JSON streams structurally, ignoring key order,
+// comments and style, and returns the changes from the old to the new one.
+// Documents are matched by kind, namespace and name, and list items by
+// their name when every item has one, so reordering shows no change.
+// Changes at or below a path matching one of ignore, which may hold *
+// wildcards as in metadata.annotations.*, are left out.
+func Diff(oldName string, oldData []byte, newName string, newData []byte, ignore []string) ([]Change, error) {
+	var patterns [][]pathSegment
+	for _, p := range ignore {
+		segs, err := parsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, segs)
+	}
+	oldObjs, err := diffObjects(oldName, oldData)
+	if err != nil {
+		return nil, err
+	}
+	newObjs, err := diffObjects(newName, newData)
+	if err != nil {
+		return nil, err
+	}
+	c := &differ{ignore: patterns}
+	if len(oldObjs) == 1 && len(newObjs) == 1 {
+		c.compare(oldObjs[0].node, newObjs[0].node, "", nil)
+		return c.changes, nil
+	}
+	matched := map[int]bool{}
+	for _, o := range oldObjs {
+		j := -1
+		for i, n := range newObjs {
+			if !matched[i] && n.name == o.name {
+				j = i
+				break
+			}
+		}
+		if j < 0 {
+			c.changes = append(c.changes, Change{Object: o.name, Type: "removed"})
+			continue
+		}
+		matched[j] = true
+		c.object = o.name
+		c.compare(o.node, newObjs[j].node, "", nil)
+	}
+	for i, n := range newObjs {
+		if !matched[i] {
+			c.changes = append(c.changes, Change{Object: n.name, Type: "added"})
+		}
+	}
+	return c.changes, nil
+}
+
+// diffObjects decodes the non-empty documents of a stream and names each
+// by kind, namespace and name, numbering those without a name.
+func diffObjects(filename string, data []byte) ([]diffObject, error) {
+	var objs []diffObject
+	err := decodeDocuments(filename, data, nil, func(root *yaml.Node) {
+		if isEmptyDocument(root) {
+			return
+		}
+		expandAliases(root, map[int]string{}, map[*yaml.Node]bool{}, map[*yaml.Node]bool{})
+		node := root.Content[0]
+		name := fmt.Sprintf("document %d", len(objs)+1)
+		if node.Kind == yaml.MappingNode {
+			d := &document{filename: filename, mapping: node, kind: objectKind(node)}
+			if obj := newObject(d, nil); obj.Name != "" {
+				name = fmt.Sprintf("%s %s/%s", obj.Kind, obj.Namespace, obj.Name)
+			}
+		}
+		objs = append(objs, diffObject{name, node})
+	})
+	return objs, err
+}
+
+// differ collects the changes between two objects.
+type differ struct {
+	ignore  [][]pathSegment
+	object  string
+	changes []Change
+}
+
+func (c *differ) add(path string, segs []pathSegment, typ string, oldNode, newNode *yaml.Node) {
+	if c.ignored(segs) {
+		return
+	}
+	ch := Change{Object: c.object, Path: path, Type: typ}
+	if oldNode != nil {
+		ch.Old = flowValue(oldNode)
+	}
+	if newNode != nil {
+		ch.New = flowValue(newNode)
+	}
+	c.changes = append(c.changes, ch)
+}
+
+// ignored reports whether segs is at or below one of the ignored paths.
+func (c *differ) ignored(segs []pathSegment) bool {
+	for _, pattern := range c.ignore {
+		if len(pattern) > len(segs) {
+			continue
+		}
+		match := true
+		for i, p := range pattern {
+			s := segs[i]
+			if p.isIndex != s.isIndex || !p.wildcard && (p.key != s.key || p.index != s.index) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// compare records the changes from a to b, found at path.
+func (c *differ) compare(a, b *yaml.Node, path string, segs []pathSegment) {
+	if c.ignored(segs) {
+		return
+	}
+	switch {
+	case a.Kind != b.Kind:
+		c.add(path, segs, "changed", a, b)
+	case a.Kind == yaml.MappingNode:
+		aEntries, bEntries := mapEntries(a), mapEntries(b)
+		for i := 0; i < len(aEntries); i += 2 {
+			key := aEntries[i].Value
+			keyPath, keySegs := joinPath(path, key), appendSegment(segs, pathSegment{key: key})
+			if bv := findMapKey(b, key); bv != nil {
+				c.compare(aEntries[i+1], bv, keyPath, keySegs)
+			} else {
+				c.add(keyPath, keySegs, "removed", aEntries[i+1], nil)
+			}
+		}
+		for i := 0; i < len(bEntries); i += 2 {
+			if key := bEntries[i].Value; findMapKey(a, key) == nil {
+				c.add(joinPath(path, key), appendSegment(segs, pathSegment{key: key}), "added", nil, bEntries[i+1])
+			}
+		}
+	case a.Kind == yaml.SequenceNode:
+		c.compareItems(a, b, path, segs)
+	case a.Value != b.Value || a.ShortTag() != b.ShortTag():
+		c.add(path, segs, "changed", a, b)
+	}
+}
+
+// compareItems compares two sequences, by the names of their items when
+// every item of both is a mapping with a distinct name, and by index
+// otherwise.
+func (c *differ) compareItems(a, b *yaml.Node, path string, segs []pathSegment) {
+	item := func(i int) (string, []pathSegment) {
+		return fmt.Sprintf("%s[%d]", path, i), appendSegment(segs, pathSegment{isIndex: true, index: i})
+	}
+	aNames, bNames := itemNames(a), itemNames(b)
+	if aNames == nil || bNames == nil {
+		for i := 0; i < max(len(a.Content), len(b.Content)); i++ {
+			p, s := item(i)
+			switch {
+			case i >= len(b.Content):
+				c.add(p, s, "removed", a.Content[i], nil)
+			case i >= len(a.Content):
+				c.add(p, s, "added", nil, b.Content[i])
+			default:
+				c.compare(a.Content[i], b.Content[i], p, s)
+			}
+		}
+		return
+	}
+	for i, name := range aNames {
+		if j := slices.Index(bNames, name); j >= 0 {
+			p, s := item(j)
+			c.compare(a.Content[i], b.Content[j], p, s)
+		} else {
+			p, s := item(i)
+			c.add(p, s, "removed", a.Content[i], nil)
+		}
+	}
+	for j, name := range bNames {
+		if !slices.Contains(aNames, name) {
+			p, s := item(j)
+			c.add(p, s, "added", nil, b.Content[j])
+		}
+	}
+}
+
+// itemNames returns the names of the items of a sequence, or nil unless
+// every item is a mapping with a name of its own.
+func itemNames(seq *yaml.Node) []string {
+	if len(seq.Content) == 0 {
+		return []string{}
+	}
+	names := make([]string, len(seq.Content))
+	seen := map[string]bool{}
+	for i, item := range seq.Content {
+		nameNode := findMapKey(item, "name")
+		if nameNode == nil || nameNode.Kind != yaml.ScalarNode || seen[nameNode.Value] {
+			return nil
+		}
+		seen[nameNode.Value] = true
+		names[i] = nameNode.Value
+	}
+	return names
+}
+
+func appendSegment(segs []pathSegment, seg pathSegment) []pathSegment {
+	return append(segs[:len(segs):len(segs)], seg)
+}
+
+// flowValue renders a value on one line, without comments: mappings and
+// sequences in flow style, and strings quoted where they would read as
+// something else, so that "2" and 2 differ.
+func flowValue(node *yaml.Node) string {
+	var flow func(n *yaml.Node) *yaml.Node
+	flow = func(n *yaml.Node) *yaml.Node {
+		cp := *n
+		cp.HeadComment, cp.LineComment, cp.FootComment = "", "", ""
+		switch {
+		case cp.Kind != yaml.ScalarNode:
+			cp.Style = yaml.FlowStyle
+		case strings.ContainsAny(cp.Value, "\n\r"):
+			cp.Style = yaml.DoubleQuotedStyle
+		default:
+			cp.Style = 0
+		}
+		cp.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			cp.Content[i] = flow(child)
+		}
+		return &cp
+	}
+	out, err := yaml.Marshal(flow(node))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
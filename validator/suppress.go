@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ignoreDirective is the comment that suppresses findings, either trailing
+// the offending line or on the line above it. "# lint-ignore" suppresses
+// every finding on the line, "# lint-ignore:rule-a,rule-b" only those of
+// the listed rules.
+const ignoreDirective = "lint-ignore"
+
+// suppression is one lint-ignore comment and the line it applies to.
+type suppression struct {
+	node  *yaml.Node
+	line  int
+	rules []string
+	used  bool
+}
+
+// collectSuppressions returns the lint-ignore comments of the document. It
+// must run before aliases are expanded, as the copies carry the comments of
+// the anchored nodes.
+func collectSuppressions(node *yaml.Node) []*suppression {
+	var found []*suppression
+	seen := map[int]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n == nil || n.Kind == yaml.AliasNode {
+			return
+		}
+		for _, comment := range []string{n.HeadComment, n.LineComment} {
+			for _, text := range strings.Split(comment, "\n") {
+				rules, ok := parseIgnore(text)
+				if !ok || seen[n.Line] {
+					continue
+				}
+				seen[n.Line] = true
+				found = append(found, &suppression{node: n, line: n.Line, rules: rules})
+			}
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+	return found
+}
+
+// parseIgnore parses a comment line, returning the rules it names and
+// whether it is a lint-ignore directive.
+func parseIgnore(text string) ([]string, bool) {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "#"))
+	rest, ok := strings.CutPrefix(text, ignoreDirective)
+	if !ok {
+		return nil, false
+	}
+	if rest == "" {
+		return nil, true
+	}
+	ids, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return nil, false
+	}
+	var rules []string
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			rules = append(rules, id)
+		}
+	}
+	return rules, true
+}
+
+// applySuppressions marks the findings silenced by a lint-ignore comment
+// and, if reportUnused is set, adds a finding for every comment that
+// silenced nothing.
+func applySuppressions(findings []Finding, sups []*suppression, reportUnused bool, filename string) []Finding {
+	for i, f := range findings {
+		for _, s := range sups {
+			if s.line == f.Line && (len(s.rules) == 0 || slices.Contains(s.rules, f.RuleID)) {
+				findings[i].Suppressed = true
+				s.used = true
+			}
+		}
+	}
+	if !reportUnused {
+		return findings
+	}
+	for _, s := range sups {
+		if !s.used {
+			f := warningAt(filename, s.node, "lint-ignore comment for line %d matches no finding", s.line)
+			f.RuleID = "unused-ignore"
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
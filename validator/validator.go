@@ -0,0 +1,507 @@
+// Package validator checks Kubernetes manifests written in YAML and reports
+// the problems it finds as Findings.
+package validator
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies how serious a finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a single problem found in a manifest. The JSON field names are
+// part of the output format of the CLI and must not be renamed.
+type Finding struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Path is the field path of the offending value, such as
+	// spec.containers[0].image.
+	Path     string   `json:"path"`
+	RuleID   string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Document is the 1-based index of the document the finding belongs to
+	// in a multi-document stream, and 0 when the stream holds one document.
+	Document int `json:"document,omitempty"`
+	// Suppressed is set when a lint-ignore comment silences the finding.
+	// Such findings are returned so they can be counted, not reported.
+	Suppressed bool `json:"suppressed,omitempty"`
+
+	// node is the node the finding is reported at, from which Path is
+	// filled in once the document has been checked.
+	node *yaml.Node
+	// missing holds the keys of an absent field below node, the closest
+	// mapping that exists. Path then names the absent field.
+	missing []string
+	// firstLine and lastLine are the lines the object of the finding
+	// spans, or zero outside an object.
+	firstLine, lastLine int
+}
+
+// AboutAbsentField reports whether the finding is about a field that is
+// not there, so that it is reported at the closest mapping that is.
+func (f Finding) AboutAbsentField() bool {
+	return len(f.missing) > 0
+}
+
+// ObjectLines returns the first and last line of the object the finding
+// belongs to, or zeros if it belongs to none.
+func (f Finding) ObjectLines() (first, last int) {
+	return f.firstLine, f.lastLine
+}
+
+// errorAt returns an error finding at the position of node. Nodes without
+// a position, such as the root of an empty document, are reported at the
+// start of the file.
+func errorAt(filename string, node *yaml.Node, format string, args ...any) Finding {
+	line, column := node.Line, node.Column
+	if line == 0 {
+		line, column = 1, 1
+	}
+	return Finding{File: filename, Line: line, Column: column, Severity: SeverityError, Message: fmt.Sprintf(format, args...), node: node}
+}
+
+// warningAt returns a warning finding at the position of node.
+func warningAt(filename string, node *yaml.Node, format string, args ...any) Finding {
+	f := errorAt(filename, node, format, args...)
+	f.Severity = SeverityWarning
+	return f
+}
+
+// requireKey returns the value of key in parent, the mapping at path. When
+// parent has no such key it returns nil and an error finding that names the
+// full path of the field and is reported at parent, the closest node that
+// exists.
+func requireKey(filename string, parent *yaml.Node, path, key string) (*yaml.Node, Finding) {
+	if node := findMapKey(parent, key); node != nil {
+		return node, Finding{}
+	}
+	return nil, missingAt(filename, parent, path, key, "is required")
+}
+
+// missingAt returns an error finding about key, absent from parent, the
+// mapping at path. The message is the full path of the field followed by
+// the formatted reason.
+func missingAt(filename string, parent *yaml.Node, path, key, format string, args ...any) Finding {
+	f := errorAt(filename, parent, "%s %s", joinPath(path, key), fmt.Sprintf(format, args...))
+	f.missing = []string{key}
+	return f
+}
+
+// closestAncestor follows keys down from node and returns the deepest
+// mapping on the way, together with the keys missing below it. The keys
+// are all present when rest is empty.
+func closestAncestor(node *yaml.Node, keys ...string) (at *yaml.Node, rest []string) {
+	at = node
+	for i, key := range keys {
+		next := findMapKey(at, key)
+		if next == nil {
+			return at, keys[i:]
+		}
+		if i == len(keys)-1 {
+			break
+		}
+		if next.Kind != yaml.MappingNode {
+			return at, keys[i:]
+		}
+		at = next
+	}
+	return at, nil
+}
+
+// Validate decodes every document of a YAML stream and returns the findings
+// for all of them. If a document fails to parse, the findings of the
+// documents before it are returned together with the error.
+func Validate(filename string, data []byte) ([]Finding, error) {
+	return ValidateWithConfig(filename, data, nil)
+}
+
+// ValidateWithConfig is like Validate but runs the rules as cfg
+// configures them. The objects of the stream are related to each other
+// only.
+func ValidateWithConfig(filename string, data []byte, cfg *Config) ([]Finding, error) {
+	res, err := ValidateStream(filename, data, cfg)
+	return SortFindings(append(res.Findings, CheckRelations(res.Objects)...)), err
+}
+
+// Result is the outcome of validating a stream.
+type Result struct {
+	Findings []Finding
+	// SkippedDocuments counts the documents left out because of their
+	// kind, as configured by Config.Kinds and Config.SkipKinds.
+	SkippedDocuments int
+	// Objects holds the objects of the stream for CheckRelations.
+	Objects []*Object
+}
+
+// ValidateStream is like ValidateWithConfig but also reports how many
+// documents were skipped, and leaves the rules relating objects to each
+// other to CheckRelations, as a run may cover several streams.
+func ValidateStream(filename string, data []byte, cfg *Config) (Result, error) {
+	start := time.Now()
+	var res Result
+	var docs [][]Finding
+	if r := lookupRule("invisible-characters"); cfg.enabled(r) && int64(len(data)) <= cfg.maxBytes() {
+		res.Findings = tagFindings(cfg, r, scanCharacters(filename, data))
+	}
+	decErr := decodeDocuments(filename, data, cfg, func(root *yaml.Node) {
+		if isEmptyDocument(root) {
+			d := &document{filename: filename, cfg: cfg, root: root}
+			docs = append(docs, runRule(d, "empty-document"))
+			return
+		}
+		var objects []*Object
+		switch {
+		case objectKind(root.Content[0]) == listKind:
+			findings, skipped, items := validateList(root, filename, cfg)
+			res.SkippedDocuments += skipped
+			docs = append(docs, findings)
+			objects = items
+		case root.Content[0].Kind == yaml.MappingNode && !cfg.checksKind(objectKind(root.Content[0])):
+			res.SkippedDocuments++
+			docs = append(docs, nil)
+		default:
+			findings, obj := validateDocument(root, filename, cfg)
+			docs = append(docs, findings)
+			if obj != nil {
+				objects = []*Object{obj}
+			}
+		}
+		for _, obj := range objects {
+			obj.Document = len(docs)
+		}
+		res.Objects = append(res.Objects, objects...)
+	})
+	if decErr == nil && len(docs) == 0 {
+		// A file with nothing but whitespace and comments has no documents
+		d := &document{filename: filename, cfg: cfg, root: &yaml.Node{Kind: yaml.DocumentNode}}
+		docs = append(docs, runRule(d, "empty-document"))
+	}
+
+	if len(docs) == 1 {
+		for _, obj := range res.Objects {
+			obj.Document = 0
+		}
+	}
+	for i, docFindings := range docs {
+		if len(docs) > 1 {
+			for j := range docFindings {
+				docFindings[j].Document = i + 1
+			}
+		}
+		res.Findings = append(res.Findings, docFindings...)
+	}
+	res.Findings = SortFindings(res.Findings)
+	recordStream(len(docs)-res.SkippedDocuments, res.Findings, decErr == nil || errors.Is(decErr, ErrLimit), time.Since(start))
+	return res, decErr
+}
+
+// SortFindings orders findings by file, line, column and rule ID, keeping
+// the order of the rules for findings at the same place, and drops those
+// repeating the file, line, rule and message of an earlier one, as happens
+// when two rules or paths reach the same node.
+func SortFindings(findings []Finding) []Finding {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column), cmp.Compare(a.RuleID, b.RuleID))
+	})
+	type key struct {
+		file    string
+		line    int
+		rule    string
+		message string
+	}
+	seen := map[key]bool{}
+	kept := findings[:0]
+	for _, f := range findings {
+		k := key{f.File, f.Line, f.RuleID, f.Message}
+		if !seen[k] {
+			seen[k] = true
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// decodeDocuments parses data and passes each of its documents to fn. Files
+// with a .json extension or starting with { are read as JSON, so positions
+// within a line come out right for minified JSON; a YAML flow mapping may
+// also start with {, so for other extensions YAML is tried when that fails.
+// Errors parsing the input as JSON wrap ErrJSON.
+// Input beyond the limits of cfg stops decoding with an error wrapping
+// ErrLimit, before fn sees the offending document.
+func decodeDocuments(filename string, data []byte, cfg *Config, fn func(root *yaml.Node)) error {
+	if limit := cfg.maxBytes(); int64(len(data)) > limit {
+		return fmt.Errorf("%w: input is larger than %d bytes", ErrLimit, limit)
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if jsonFile := strings.EqualFold(filepath.Ext(filename), ".json"); jsonFile || startsObject(data) {
+		roots, err := parseJSON(data)
+		if err == nil || jsonFile {
+			for i, root := range roots {
+				if err := checkLimits(root, i+1, cfg); err != nil {
+					return err
+				}
+				fn(root)
+			}
+			return err
+		}
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for doc := 1; ; doc++ {
+		var root yaml.Node
+		if err := dec.Decode(&root); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := checkLimits(&root, doc, cfg); err != nil {
+			return err
+		}
+		fn(&root)
+	}
+}
+
+// isEmptyDocument reports whether a document holds nothing but a null.
+func isEmptyDocument(root *yaml.Node) bool {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return true
+	}
+	n := root.Content[0]
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// validateEmptyDocument reports a document that holds nothing but a null,
+// at the null if there is one and at the start of the file otherwise.
+func validateEmptyDocument(d *document) []Finding {
+	if !isEmptyDocument(d.root) {
+		return nil
+	}
+	at := d.root
+	if len(d.root.Content) > 0 {
+		at = d.root.Content[0]
+	}
+	return []Finding{warningAt(d.filename, at, "document is empty")}
+}
+
+// validateDocument returns the findings for a single parsed document.
+func validateDocument(root *yaml.Node, filePath string, cfg *Config) ([]Finding, *Object) {
+	d := &document{filename: filePath, cfg: cfg, root: root, copies: map[*yaml.Node]bool{}}
+	// Determine root mapping node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		d.mapping = root.Content[0]
+	} else {
+		d.mapping = root
+	}
+
+	sups := collectSuppressions(root)
+	if d.mapping.Kind != yaml.MappingNode {
+		// Nothing else can be checked in a document that is not an object
+		errs := runRule(d, "object-header")
+		fillPaths(root, errs)
+		return applySuppressions(errs, sups, false, filePath), nil
+	}
+
+	// Expand aliases so anchored content is validated where it is used
+	aliasUses := map[int]string{}
+	expandAliases(root, aliasUses, d.copies, map[*yaml.Node]bool{})
+
+	// Locate the pod spec, if the kind has one
+	d.kind = objectKind(d.mapping)
+	d.spec, d.specPath, d.specErrs = findPodSpec(d.mapping, d.kind, filePath)
+
+	errs := runRules(d)
+	fillPaths(root, errs)
+	setObjectLines(d.mapping, errs)
+	annotateAliasUses(errs, aliasUses)
+	reportUnused := cfg != nil && cfg.ReportUnusedIgnores && cfg.rule("unused-ignore").Severity != SeverityOff
+	return applySuppressions(errs, sups, reportUnused, filePath), newObject(d, sups)
+}
+
+// fillPaths sets the Path of each finding from the node it is reported at,
+// followed by the keys of the absent field it is about, if any.
+func fillPaths(root *yaml.Node, findings []Finding) {
+	paths := nodePaths(root)
+	for i := range findings {
+		findings[i].Path = paths[findings[i].node]
+		for _, key := range findings[i].missing {
+			findings[i].Path = joinPath(findings[i].Path, key)
+		}
+	}
+}
+
+// setObjectLines records the lines spanned by obj in each finding.
+func setObjectLines(obj *yaml.Node, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	last := obj.Line
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		last = max(last, n.Line)
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(obj)
+	for i := range findings {
+		findings[i].firstLine, findings[i].lastLine = obj.Line, last
+	}
+}
+
+func findMapKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	// Mapping node Content has [key0, val0, key1, val1, ...]
+	entries := mapEntries(node)
+	for i := 0; i < len(entries); i += 2 {
+		k := entries[i]
+		if k.Kind == yaml.ScalarNode && k.Value == key {
+			return entries[i+1]
+		}
+	}
+	return nil
+}
+
+// isMergeKey reports whether k is the YAML merge key <<.
+func isMergeKey(k *yaml.Node) bool {
+	return k.Kind == yaml.ScalarNode && k.Tag == "!!merge"
+}
+
+// mapEntries returns the key/value pairs of a mapping in the same layout as
+// Content, with merge keys (<<) applied: direct keys override merged ones,
+// and within a sequence of merged mappings earlier ones win.
+func mapEntries(node *yaml.Node) []*yaml.Node {
+	return mergeEntries(node, map[*yaml.Node]bool{})
+}
+
+func mergeEntries(node *yaml.Node, visiting map[*yaml.Node]bool) []*yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode || visiting[node] {
+		return nil
+	}
+	var entries []*yaml.Node
+	var merged []*yaml.Node
+	hasMerge := false
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if !isMergeKey(k) {
+			entries = append(entries, k, v)
+			continue
+		}
+		hasMerge = true
+		visiting[node] = true
+		if v = resolveAlias(v); v != nil && v.Kind == yaml.SequenceNode {
+			for _, item := range v.Content {
+				merged = append(merged, mergeEntries(item, visiting)...)
+			}
+		} else {
+			merged = append(merged, mergeEntries(v, visiting)...)
+		}
+		delete(visiting, node)
+	}
+	if !hasMerge {
+		return node.Content
+	}
+	seen := map[string]bool{}
+	for i := 0; i < len(entries); i += 2 {
+		seen[entries[i].Value] = true
+	}
+	for i := 0; i < len(merged); i += 2 {
+		if k := merged[i]; !seen[k.Value] {
+			seen[k.Value] = true
+			entries = append(entries, k, merged[i+1])
+		}
+	}
+	return entries
+}
+
+// validateEnum reports a scalar whose value is not one of allowed. When the
+// value only differs from an allowed one by case, the correct casing is
+// suggested.
+func validateEnum(valNode *yaml.Node, field string, allowed []string, filename string) []Finding {
+	if valNode.Kind != yaml.ScalarNode {
+		return []Finding{errorAt(filename, valNode, "%s must be string", field)}
+	}
+	for _, a := range allowed {
+		if valNode.Value == a {
+			return nil
+		}
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(valNode.Value, a) {
+			return []Finding{errorAt(filename, valNode, "%s has unsupported value '%s' (did you mean '%s'?)", field, valNode.Value, a)}
+		}
+	}
+	return []Finding{errorAt(filename, valNode, "%s has unsupported value '%s'", field, valNode.Value)}
+}
+
+// parseIntScalar returns the integer held by a scalar node. Quoted integers
+// such as "30" are accepted, and surrounding whitespace left by block
+// scalars is ignored.
+func parseIntScalar(node *yaml.Node) (int64, bool) {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return 0, false
+	}
+	if node.Tag != "!!int" && node.Tag != "!!str" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(node.Value), 10, 64)
+	return v, err == nil
+}
+
+// validateMinInt reports a node that does not hold an integer of at least min.
+func validateMinInt(node *yaml.Node, field string, min int64, filename string) []Finding {
+	v, ok := parseIntScalar(node)
+	if !ok {
+		if strings.HasSuffix(field, "Seconds") {
+			return []Finding{errorAt(filename, node, "%s must be a bare integer number of seconds, got '%s'", field, node.Value)}
+		}
+		return []Finding{errorAt(filename, node, "%s must be an integer", field)}
+	}
+	if v < min {
+		return []Finding{errorAt(filename, node, "%s must be at least %d", field, min)}
+	}
+	return nil
+}
+
+func validateOS(specNode *yaml.Node, specPath string, allowed []string, filename string) []Finding {
+	var errs []Finding
+	osNode := findMapKey(specNode, "os")
+	if osNode != nil {
+		if osNode.Kind == yaml.ScalarNode {
+			if !slices.Contains(allowed, osNode.Value) {
+				errs = append(errs, errorAt(filename, osNode, "os has unsupported value '%s'", osNode.Value))
+			}
+		} else if osNode.Kind == yaml.MappingNode {
+			if nameNode, missing := requireKey(filename, osNode, joinPath(specPath, "os"), "name"); nameNode == nil {
+				errs = append(errs, missing)
+			} else if nameNode.Kind != yaml.ScalarNode {
+				errs = append(errs, errorAt(filename, nameNode, "os.name must be string"))
+			} else if !slices.Contains(allowed, nameNode.Value) {
+				errs = append(errs, errorAt(filename, nameNode, "os has unsupported value '%s'", nameNode.Value))
+			}
+		} else {
+			errs = append(errs, errorAt(filename, osNode, "os must be string or object"))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateFixture validates the file name of testdata with cfg.
+func validateFixture(t *testing.T, name string, cfg *Config) []Finding {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := ValidateWithConfig(name, data, cfg)
+	if err != nil {
+		t.Fatalf("ValidateWithConfig(%s): %v", name, err)
+	}
+	return findings
+}
+
+// validateString validates the manifest src with cfg.
+func validateString(t *testing.T, src string, cfg *Config) []Finding {
+	t.Helper()
+	findings, err := ValidateWithConfig("test.yaml", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("ValidateWithConfig: %v", err)
+	}
+	return findings
+}
+
+// ofRule returns the findings of the rule id.
+func ofRule(findings []Finding, id string) []Finding {
+	var kept []Finding
+	for _, f := range findings {
+		if f.RuleID == id {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// brief renders findings as line:column severity message, one per
+// element, for comparing with what a test expects.
+func brief(findings []Finding) []string {
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("%d:%d %s %s", f.Line, f.Column, f.Severity, f.Message))
+	}
+	return lines
+}
+
+// checkFindings fails the test unless findings are want, as given by brief.
+func checkFindings(t *testing.T, findings []Finding, want []string) {
+	t.Helper()
+	if got := brief(findings); !slices.Equal(got, want) {
+		t.Errorf("findings:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+}
+
+func TestSortFindings(t *testing.T) {
+	in := []Finding{
+		{File: "b.yaml", Line: 1, Column: 1, RuleID: "kind", Message: "m1"},
+		{File: "a.yaml", Line: 9, Column: 3, RuleID: "labels", Message: "m2"},
+		{File: "a.yaml", Line: 2, Column: 5, RuleID: "service", Message: "m3"},
+		{File: "a.yaml", Line: 2, Column: 5, RuleID: "image-tag", Message: "m4"},
+		{File: "a.yaml", Line: 2, Column: 1, RuleID: "service", Message: "m5"},
+		// Repeats m3 at another column, as when two paths reach one node
+		{File: "a.yaml", Line: 2, Column: 7, RuleID: "service", Message: "m3"},
+		{File: "a.yaml", Line: 9, Column: 3, RuleID: "labels", Message: "m6"},
+	}
+	var got []string
+	for _, f := range SortFindings(in) {
+		got = append(got, f.Message)
+	}
+	if want := []string{"m5", "m4", "m3", "m2", "m6", "m1"}; !slices.Equal(got, want) {
+		t.Errorf("SortFindings order = %v, want %v", got, want)
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	// Each finding names the full path of the missing field and is placed
+	// at the closest mapping that exists, however deep it is
+	var missing []Finding
+	for _, f := range validateFixture(t, "missing-fields.yaml", nil) {
+		if f.AboutAbsentField() && f.Severity == SeverityError {
+			missing = append(missing, f)
+		}
+	}
+	checkFindings(t, missing, []string{
+		"4:3 error metadata.name is required",
+		"13:15 error spec.jobTemplate.spec.template.spec.os.name is required",
+		"17:13 error spec.jobTemplate.spec.template.spec.containers[1].name is required",
+		"28:5 error spec.template.spec is required",
+		"32:1 error spec is required",
+	})
+}
+
+func TestClosestAncestor(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("a:\n  b:\n    c: 1\n  s: text\n"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+	tests := []struct {
+		keys     []string
+		wantLine int
+		wantRest []string
+	}{
+		{[]string{"a", "b", "c"}, 3, nil},
+		{[]string{"a", "b", "d"}, 3, []string{"d"}},
+		{[]string{"a", "x", "y"}, 2, []string{"x", "y"}},
+		{[]string{"x", "y", "z"}, 1, []string{"x", "y", "z"}},
+		// A scalar on the way cannot hold the rest
+		{[]string{"a", "s", "t"}, 2, []string{"s", "t"}},
+	}
+	for _, tt := range tests {
+		at, rest := closestAncestor(root, tt.keys...)
+		if at.Line != tt.wantLine || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("closestAncestor(%v) = line %d, %v; want line %d, %v", tt.keys, at.Line, rest, tt.wantLine, tt.wantRest)
+		}
+	}
+}
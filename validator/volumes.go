@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateVolumeMounts cross-checks every container's volumeMounts against
+// the volumes declared in spec.volumes.
+func validateVolumeMounts(specNode *yaml.Node, specPath, filename string) []Finding {
+	var errs []Finding
+	declared := map[string]*yaml.Node{}
+	var order []string
+	if volsNode := findMapKey(specNode, "volumes"); volsNode != nil && volsNode.Kind == yaml.SequenceNode {
+		for _, vol := range volsNode.Content {
+			nameNode := findMapKey(vol, "name")
+			if nameNode == nil || nameNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			if _, ok := declared[nameNode.Value]; !ok {
+				order = append(order, nameNode.Value)
+			}
+			declared[nameNode.Value] = nameNode
+		}
+	}
+
+	used := map[string]bool{}
+	forEachContainer(specNode, specPath, func(contNode *yaml.Node, path string, init bool) {
+		mountsNode := findMapKey(contNode, "volumeMounts")
+		if mountsNode == nil || mountsNode.Kind != yaml.SequenceNode {
+			return
+		}
+		paths := map[string]bool{}
+		for i, mount := range mountsNode.Content {
+			if mount.Kind != yaml.MappingNode {
+				continue
+			}
+			field := fmt.Sprintf("%s.volumeMounts[%d]", path, i)
+			if nameNode := findMapKey(mount, "name"); nameNode != nil {
+				used[nameNode.Value] = true
+				if _, ok := declared[nameNode.Value]; !ok {
+					errs = append(errs, errorAt(filename, nameNode, "%s.name refers to unknown volume '%s'", field, nameNode.Value))
+				}
+			}
+			pathNode := findMapKey(mount, "mountPath")
+			if pathNode == nil || pathNode.Value == "" {
+				errs = append(errs, errorAt(filename, mount, "%s.mountPath must not be empty", field))
+				continue
+			}
+			if paths[pathNode.Value] {
+				errs = append(errs, errorAt(filename, pathNode, "%s.mountPath duplicates mount path '%s'", field, pathNode.Value))
+			}
+			paths[pathNode.Value] = true
+		}
+	})
+
+	for _, name := range order {
+		if !used[name] {
+			errs = append(errs, warningAt(filename, declared[name], "%s.volumes volume '%s' is not mounted by any container", specPath, name))
+		}
+	}
+	return errs
+}
+
+// validateVolumes checks the structure of each entry in spec.volumes.
+func validateVolumes(specNode *yaml.Node, specPath, filename string) []Finding {
+	volsNode := findMapKey(specNode, "volumes")
+	if volsNode == nil || volsNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var errs []Finding
+	seen := map[string]bool{}
+	for i, vol := range volsNode.Content {
+		if vol.Kind != yaml.MappingNode {
+			continue
+		}
+		field := fmt.Sprintf("%s.volumes[%d]", specPath, i)
+		nameNode, missing := requireKey(filename, vol, field, "name")
+		if nameNode == nil {
+			errs = append(errs, missing)
+		} else {
+			if !isDNS1123Label(nameNode.Value) {
+				errs = append(errs, errorAt(filename, nameNode, "%s.name '%s' is not a valid DNS-1123 label", field, nameNode.Value))
+			}
+			if seen[nameNode.Value] {
+				errs = append(errs, errorAt(filename, nameNode, "%s.name duplicates volume name '%s'", field, nameNode.Value))
+			}
+			seen[nameNode.Value] = true
+		}
+
+		// Every key besides name is a volume source
+		var sources []string
+		entries := mapEntries(vol)
+		for i := 0; i < len(entries); i += 2 {
+			if k := entries[i].Value; k != "name" {
+				sources = append(sources, k)
+			}
+		}
+		switch len(sources) {
+		case 0:
+			errs = append(errs, errorAt(filename, vol, "%s must specify exactly one volume source", field))
+			continue
+		case 1:
+		default:
+			errs = append(errs, errorAt(filename, vol, "%s must specify exactly one volume source, found %s", field, strings.Join(sources, ", ")))
+		}
+		for _, src := range sources {
+			errs = append(errs, validateVolumeSource(findMapKey(vol, src), field+"."+src, src, filename)...)
+		}
+	}
+	return errs
+}
+
+func validateVolumeSource(srcNode *yaml.Node, field, src, filename string) []Finding {
+	var errs []Finding
+	if srcNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	switch src {
+	case "emptyDir":
+		if sizeNode := findMapKey(srcNode, "sizeLimit"); sizeNode != nil {
+			if _, err := parseQuantity(sizeNode.Value, memorySuffixes); err != nil {
+				errs = append(errs, errorAt(filename, sizeNode, "%s.sizeLimit %v", field, err))
+			}
+		}
+		if mediumNode := findMapKey(srcNode, "medium"); mediumNode != nil {
+			errs = append(errs, validateEnum(mediumNode, field+".medium", []string{"", "Memory"}, filename)...)
+		}
+	case "configMap":
+		errs = append(errs, validateRefName(srcNode, field, filename)...)
+	case "secret":
+		if nameNode, missing := requireKey(filename, srcNode, field, "secretName"); nameNode == nil {
+			errs = append(errs, missing)
+		} else if !isDNS1123Subdomain(nameNode.Value) {
+			errs = append(errs, errorAt(filename, nameNode, "%s.secretName '%s' is not a valid DNS-1123 subdomain", field, nameNode.Value))
+		}
+	case "persistentVolumeClaim":
+		if claimNode, missing := requireKey(filename, srcNode, field, "claimName"); claimNode == nil {
+			errs = append(errs, missing)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,171 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// podSpecPaths maps each kind that embeds a pod spec to the path of that
+// spec within the object.
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// replicatedKinds lists the kinds with a spec.replicas field.
+var replicatedKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"ReplicaSet":  true,
+}
+
+// selectorKinds lists the kinds whose spec.selector must match the labels
+// of their pod template.
+var selectorKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// KnownKind reports whether the rules check anything specific to kind.
+// Objects of other kinds only get the checks every object gets.
+func KnownKind(kind string) bool {
+	_, ok := podSpecPaths[kind]
+	return ok || kind == "Service"
+}
+
+// builtinKinds lists the kinds served by a cluster out of the box, which
+// kind compares unknown kinds against to catch typos.
+var builtinKinds = map[string]bool{
+	"Pod": true, "Deployment": true, "StatefulSet": true, "DaemonSet": true, "ReplicaSet": true,
+	"Job": true, "CronJob": true, "ReplicationController": true, "PodTemplate": true,
+	"Service": true, "Endpoints": true, "EndpointSlice": true, "Ingress": true, "IngressClass": true,
+	"NetworkPolicy": true, "ConfigMap": true, "Secret": true, "Namespace": true, "ServiceAccount": true,
+	"Role": true, "RoleBinding": true, "ClusterRole": true, "ClusterRoleBinding": true,
+	"PersistentVolume": true, "PersistentVolumeClaim": true, "StorageClass": true,
+	"HorizontalPodAutoscaler": true, "PodDisruptionBudget": true, "PriorityClass": true,
+	"LimitRange": true, "ResourceQuota": true, "CustomResourceDefinition": true,
+	"MutatingWebhookConfiguration": true, "ValidatingWebhookConfiguration": true,
+	"RuntimeClass": true, "Lease": true, "CSIDriver": true, "CSINode": true, "VolumeAttachment": true,
+	"Node": true, "Event": true, "Binding": true, "ControllerRevision": true, "APIService": true,
+	"CertificateSigningRequest": true, "List": true,
+}
+
+// validateKind warns about a kind that is not built in but is close to one
+// that is, such as Deploymnet, since objects of unknown kinds only get the
+// checks every object gets.
+func validateKind(d *document) []Finding {
+	kindNode := findMapKey(d.mapping, "kind")
+	if kindNode == nil || kindNode.Kind != yaml.ScalarNode || builtinKinds[kindNode.Value] {
+		return nil
+	}
+	if s := closestName(kindNode.Value, builtinKinds); s != "" {
+		return []Finding{warningAt(d.filename, kindNode, "kind '%s' is not a known kind (did you mean '%s'?)", kindNode.Value, s)}
+	}
+	return nil
+}
+
+var labelSelectorOperators = []string{"In", "NotIn", "Exists", "DoesNotExist"}
+
+// objectKind returns the kind of the object. Documents without a kind are
+// treated as Pods, which is what this tool originally validated.
+func objectKind(mapping *yaml.Node) string {
+	kindNode := findMapKey(mapping, "kind")
+	if kindNode == nil || kindNode.Kind != yaml.ScalarNode || kindNode.Value == "" {
+		return "Pod"
+	}
+	return kindNode.Value
+}
+
+// findPodSpec locates the pod spec of an object of the given kind and
+// returns it with its field path. Kinds without a pod spec return nil and
+// no findings; kinds with one report a missing or malformed spec.
+func findPodSpec(mapping *yaml.Node, kind, filename string) (*yaml.Node, string, []Finding) {
+	segments, ok := podSpecPaths[kind]
+	if !ok {
+		return nil, "", nil
+	}
+	node := mapping
+	for i, seg := range segments {
+		path := strings.Join(segments[:i+1], ".")
+		next, missing := requireKey(filename, node, strings.Join(segments[:i], "."), seg)
+		if next == nil {
+			return nil, "", []Finding{missing}
+		}
+		if next.Kind != yaml.MappingNode {
+			return nil, "", []Finding{errorAt(filename, next, "%s must be a mapping", path)}
+		}
+		node = next
+	}
+	specPath := strings.Join(segments, ".")
+	var errs []Finding
+	contsNode := findMapKey(node, "containers")
+	if contsNode == nil {
+		errs = append(errs, missingAt(filename, node, specPath, "containers", "is required and must be a non-empty list"))
+	} else if contsNode.Kind != yaml.SequenceNode || len(contsNode.Content) == 0 {
+		errs = append(errs, errorAt(filename, contsNode, "%s.containers is required and must be a non-empty list", specPath))
+	}
+	return node, specPath, errs
+}
+
+func validateReplicas(d *document) []Finding {
+	if !replicatedKinds[d.kind] {
+		return nil
+	}
+	if replicas := findMapKey(findMapKey(d.mapping, "spec"), "replicas"); replicas != nil {
+		return validateMinInt(replicas, "spec.replicas", 0, d.filename)
+	}
+	return nil
+}
+
+// validateSelector checks that spec.selector is present, that its
+// matchExpressions are well formed and that every matchLabels entry is also
+// set, with the same value, on the pod template.
+func validateSelector(d *document) []Finding {
+	if !selectorKinds[d.kind] {
+		return nil
+	}
+	filename := d.filename
+	specNode := findMapKey(d.mapping, "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	selNode, missing := requireKey(filename, specNode, "spec", "selector")
+	if selNode == nil {
+		return []Finding{missing}
+	}
+	if selNode.Kind != yaml.MappingNode {
+		return []Finding{errorAt(filename, selNode, "spec.selector must be a mapping")}
+	}
+	var errs []Finding
+	if expsNode := findMapKey(selNode, "matchExpressions"); expsNode != nil && expsNode.Kind == yaml.SequenceNode {
+		for i, exp := range expsNode.Content {
+			field := fmt.Sprintf("spec.selector.matchExpressions[%d]", i)
+			errs = append(errs, validateSelectorRequirement(exp, field, labelSelectorOperators, filename)...)
+		}
+	}
+	matchNode := findMapKey(selNode, "matchLabels")
+	if matchNode == nil || matchNode.Kind != yaml.MappingNode {
+		return errs
+	}
+	errs = append(errs, validateLabelMap(matchNode, "spec.selector.matchLabels", true, filename)...)
+	labelsNode := findMapKey(findMapKey(findMapKey(specNode, "template"), "metadata"), "labels")
+	entries := mapEntries(matchNode)
+	for i := 0; i < len(entries); i += 2 {
+		k, v := entries[i], entries[i+1]
+		label := findMapKey(labelsNode, k.Value)
+		if label == nil {
+			errs = append(errs, errorAt(filename, k, "spec.selector.matchLabels '%s' is not set in spec.template.metadata.labels", k.Value))
+		} else if label.Value != v.Value {
+			errs = append(errs, errorAt(filename, k, "spec.selector.matchLabels '%s: %s' does not match spec.template.metadata.labels value '%s'", k.Value, v.Value, label.Value))
+		}
+	}
+	return errs
+}
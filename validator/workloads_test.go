@@ -0,0 +1,26 @@
+package validator
+
+import "testing"
+
+func TestWorkloads(t *testing.T) {
+	const cpu = "error spec.template.spec.containers[0].resources.limits.cpu is not a valid quantity"
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"workload-pod.yaml", []string{"17:14 error spec.containers[0].resources.limits.cpu is not a valid quantity"}},
+		{"workload-deployment.yaml", []string{"6:13 error spec.replicas must be at least 0", "26:18 " + cpu}},
+		{"workload-statefulset.yaml", []string{"6:13 error spec.replicas must be at least 0", "27:18 " + cpu}},
+		{"workload-daemonset.yaml", []string{"25:18 " + cpu}},
+		{"workload-replicaset.yaml", []string{"6:13 error spec.replicas must be at least 0", "26:18 " + cpu}},
+		{"workload-job.yaml", []string{"20:18 " + cpu}},
+		{"workload-cronjob.yaml", []string{"23:22 error spec.jobTemplate.spec.template.spec.containers[0].resources.limits.cpu is not a valid quantity"}},
+		// Pod-spec rules and spec.replicas are left alone in other kinds
+		{"workload-unknown.yaml", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			checkFindings(t, validateFixture(t, tt.file, nil), tt.want)
+		})
+	}
+}
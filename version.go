@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-test-maga/version"
+)
+
+// printVersion prints the build information as text or JSON.
+func printVersion(format string) int {
+	info := version.Get()
+	switch format {
+	case "json":
+		return writeExplainJSON(os.Stdout, info)
+	case "text":
+		fmt.Printf("%s %s\n", toolName, info)
+		return exitClean
+	}
+	fmt.Fprintf(os.Stderr, "unknown format %q, want text or json\n", format)
+	return exitUsage
+}
+
+// versionCommand is the version subcommand, the same as --version.
+func versionCommand(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s version [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	return printVersion(*format)
+}
@@ -0,0 +1,66 @@
+// Package version holds the version of the build, set with -ldflags at
+// build time:
+//
+//	go build -ldflags "-X go-test-maga/version.Version=1.4.0 -X go-test-maga/version.Commit=$(git rev-parse HEAD) -X go-test-maga/version.Date=$(date -u +%FT%TZ)"
+//
+// A plain go build reports the version devel, with the commit and date
+// the go command records for a build inside a git checkout.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X.
+var (
+	Version = "devel"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the information of the running build, taking what -ldflags
+// left unset from the build information of the binary.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// String renders the information on one line, as printed by --version.
+func (i Info) String() string {
+	s := i.Version
+	if i.Commit != "" {
+		s += " (commit " + short(i.Commit)
+		if i.Date != "" {
+			s += ", built " + i.Date
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s %s", s, i.GoVersion)
+}
+
+func short(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go-test-maga/validator"
+)
+
+// watchDebounce is how long a changed file must stay quiet before it is
+// checked again, so an editor saving in several writes triggers one check.
+const watchDebounce = 200 * time.Millisecond
+
+// watcher re-checks the files named on the command line as they change.
+type watcher struct {
+	fs   *fsnotify.Watcher
+	walk *walker
+	// dirs are the directories given as arguments and globs the glob
+	// patterns, in slash form; files are the other arguments.
+	dirs  []string
+	globs []string
+	files map[string]bool
+	// findings holds the current findings of every known file.
+	findings map[string][]validator.Finding
+}
+
+// newWatcher watches args and the files expanded from them. initial holds
+// the findings of the first run, keyed by file.
+func newWatcher(args, files []string, walk *walker, initial map[string][]validator.Finding) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{fs: fsw, walk: walk, files: map[string]bool{}, findings: map[string][]validator.Finding{}}
+	for _, f := range files {
+		w.findings[filepath.Clean(f)] = initial[f]
+	}
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			w.dirs = append(w.dirs, filepath.Clean(arg))
+			w.addTree(arg)
+		} else if strings.ContainsAny(arg, "*?[") {
+			pattern := filepath.ToSlash(filepath.Clean(arg))
+			w.globs = append(w.globs, pattern)
+			w.addTree(globBase(pattern))
+		} else {
+			w.files[filepath.Clean(arg)] = true
+			// Watch the directory so files replaced by renaming are seen
+			if err := fsw.Add(filepath.Dir(arg)); err != nil {
+				fsw.Close()
+				return nil, err
+			}
+		}
+	}
+	return w, nil
+}
+
+// addTree watches dir and the directories below it, and returns the
+// manifests found in them.
+func (w *watcher) addTree(dir string) []string {
+	var found []string
+	filepath.WalkDir(dir, func(p string, e os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !e.IsDir() {
+			found = append(found, p)
+			return nil
+		}
+		if p != dir && w.walk.skipDir(e.Name()) {
+			return filepath.SkipDir
+		}
+		w.fs.Add(p)
+		return nil
+	})
+	return found
+}
+
+// wanted reports whether p is one of the files the arguments select. Of
+// the ignore rules only --exclude is applied to new files.
+func (w *watcher) wanted(p string) bool {
+	if w.files[p] {
+		return true
+	}
+	if w.walk.ignored(nil, p, false) {
+		return false
+	}
+	for _, dir := range w.dirs {
+		if isManifest(p) && underDir(p, dir) {
+			return true
+		}
+	}
+	for _, pattern := range w.globs {
+		if globMatch(pattern, filepath.ToSlash(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// underDir reports whether the clean path p lies below dir.
+func underDir(p, dir string) bool {
+	return dir == "." && !filepath.IsAbs(p) && !strings.HasPrefix(p, "..") ||
+		strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// run waits for changes and returns the exit code for the findings current
+// when it is interrupted. Each changed file is checked with recheck, which
+// prints and returns its findings, and done gets the combined findings and
+// the time the checks took.
+func (w *watcher) run(p painter, recheck func(filePath string) []validator.Finding, done func(files []string, all []validator.Finding, elapsed time.Duration), maxWarnings int) int {
+	defer w.fs.Close()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	pending := map[string]bool{}
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-interrupt:
+			return exitCode(w.all(), maxWarnings)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return exitCode(w.all(), maxWarnings)
+			}
+			fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return exitCode(w.all(), maxWarnings)
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			name := filepath.Clean(ev.Name)
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					// Pick up the files of new directories too
+					for _, f := range w.addTree(name) {
+						if f = filepath.Clean(f); w.wanted(f) {
+							pending[f] = true
+						}
+					}
+					timer.Reset(watchDebounce)
+					continue
+				}
+			}
+			if _, known := w.findings[name]; known || w.wanted(name) {
+				pending[name] = true
+				timer.Reset(watchDebounce)
+			}
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for f := range pending {
+				changed = append(changed, f)
+			}
+			slices.Sort(changed)
+			clear(pending)
+			begin := time.Now()
+			stamp := p.paint(ansiBold, "["+time.Now().Format("15:04:05")+"]")
+			for _, f := range changed {
+				if info, err := os.Stat(f); err != nil || info.IsDir() {
+					if _, known := w.findings[f]; known {
+						delete(w.findings, f)
+						fmt.Fprintf(os.Stderr, "%s %s removed\n", stamp, f)
+					}
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "%s %s\n", stamp, f)
+				w.findings[f] = recheck(f)
+			}
+			done(w.known(), w.all(), time.Since(begin))
+		}
+	}
+}
+
+// known returns the files being watched, sorted.
+func (w *watcher) known() []string {
+	files := make([]string, 0, len(w.findings))
+	for f := range w.findings {
+		files = append(files, f)
+	}
+	slices.Sort(files)
+	return files
+}
+
+// all returns the current findings of every file, in file order.
+func (w *watcher) all() []validator.Finding {
+	var all []validator.Finding
+	for _, f := range w.known() {
+		all = append(all, w.findings[f]...)
+	}
+	return all
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go-test-maga/validator"
+)
+
+// The subset of the admission.k8s.io/v1 AdmissionReview used by the
+// webhook.
+type (
+	admissionReview struct {
+		APIVersion string             `json:"apiVersion"`
+		Kind       string             `json:"kind"`
+		Request    *admissionRequest  `json:"request,omitempty"`
+		Response   *admissionResponse `json:"response,omitempty"`
+	}
+	admissionRequest struct {
+		UID  string `json:"uid"`
+		Kind struct {
+			Kind string `json:"kind"`
+		} `json:"kind"`
+		Name      string          `json:"name"`
+		Namespace string          `json:"namespace"`
+		Object    json.RawMessage `json:"object"`
+	}
+	admissionResponse struct {
+		UID      string           `json:"uid"`
+		Allowed  bool             `json:"allowed"`
+		Status   *admissionStatus `json:"status,omitempty"`
+		Warnings []string         `json:"warnings,omitempty"`
+	}
+	admissionStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+// webhook runs the webhook subcommand, a validating admission webhook, and
+// returns the exit code.
+func webhook(args []string) int {
+	fs := flag.NewFlagSet("webhook", flag.ContinueOnError)
+	listen := fs.String("listen", ":8443", "address to listen on")
+	certFile := fs.String("tls-cert-file", "", "TLS certificate file (required)")
+	keyFile := fs.String("tls-key-file", "", "TLS private key file (required)")
+	mode := fs.String("mode", "deny", "deny: reject objects with error findings; warn: admit them with warnings")
+	maxBody := fs.Int64("max-body-bytes", validator.DefaultMaxBytes, "largest request body accepted, in bytes")
+	maxNodes := fs.Int("max-nodes", validator.DefaultMaxNodes, "most nodes a document may hold once its aliases are expanded")
+	maxDepth := fs.Int("max-depth", validator.DefaultMaxDepth, "deepest a document may nest")
+	configPath := fs.String("config", "", "configuration file to use instead of the nearest "+configFileName+" above the working directory")
+	enable := fs.String("enable", "", "comma-separated rule IDs to enable even if a configuration file turns them off")
+	disable := fs.String("disable", "", "comma-separated rule IDs to disable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s webhook --tls-cert-file <file> --tls-key-file <file> [flags]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Serves AdmissionReview v1 requests on POST /validate, GET /healthz, and GET /metrics for Prometheus.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *certFile == "" || *keyFile == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	if *mode != "deny" && *mode != "warn" {
+		fmt.Fprintf(os.Stderr, "unknown mode %q, want deny or warn\n", *mode)
+		return exitUsage
+	}
+	if *maxBody < 0 || *maxNodes < 0 || *maxDepth < 0 {
+		fmt.Fprintln(os.Stderr, "--max-body-bytes, --max-nodes and --max-depth must not be negative")
+		return exitUsage
+	}
+	// 0 stands for the default, as it does in the configuration file
+	if *maxBody == 0 {
+		*maxBody = validator.DefaultMaxBytes
+	}
+	cfgs, err := newConfigs(*configPath, *enable, *disable)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// Requests are untrusted, so the limits hold whatever a configuration
+	// file says
+	cfgs.maxBytes, cfgs.maxNodes, cfgs.maxDepth = *maxBody, *maxNodes, *maxDepth
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("POST /validate", countRequests(admissionHandler(cfgs, *maxBody, *mode == "deny")))
+	mux.Handle("GET /metrics", metricsHandler())
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return runServer(srv, func() error { return srv.ListenAndServeTLS(*certFile, *keyFile) })
+}
+
+// admissionHandler reviews the object of an AdmissionReview. Warnings are
+// returned in the response so kubectl shows them; errors deny the object
+// when deny is set and are returned as warnings otherwise. Objects of kinds
+// the rules know nothing about are always allowed.
+func admissionHandler(cfgs *configs, maxBody int64, deny bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error reading request body: %v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
+		var review admissionReview
+		if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+			http.Error(w, "request body is not an AdmissionReview with a request", http.StatusBadRequest)
+			return
+		}
+		req := review.Request
+		resp := &admissionResponse{UID: req.UID, Allowed: true}
+		review.Request, review.Response = nil, resp
+		defer func() {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(review)
+		}()
+
+		// Deletions carry no object
+		if !validator.KnownKind(req.Kind.Kind) || len(req.Object) == 0 || string(req.Object) == "null" {
+			return
+		}
+		cfg, err := cfgs.forFile("-")
+		if err != nil {
+			resp.Warnings = []string{fmt.Sprintf("Error loading configuration: %v", err)}
+			return
+		}
+		// JSON is YAML, so the object is validated as it is
+		name := req.Kind.Kind + "/" + req.Name
+		if req.Namespace != "" {
+			name = req.Namespace + "/" + name
+		}
+		findings, err := validator.ValidateWithConfig(name, req.Object, cfg)
+		if errors.Is(err, validator.ErrLimit) && deny {
+			resp.Allowed = false
+			resp.Status = &admissionStatus{Code: http.StatusRequestEntityTooLarge, Message: err.Error()}
+			return
+		}
+		if err != nil {
+			resp.Warnings = []string{fmt.Sprintf("Error parsing object: %v", err)}
+			return
+		}
+		findings, _ = dropSuppressed(findings)
+		var errs []string
+		for _, f := range findings {
+			text := fmt.Sprintf("%s (%s)", f.Message, f.RuleID)
+			if f.Severity == validator.SeverityError && deny {
+				errs = append(errs, text)
+			} else {
+				resp.Warnings = append(resp.Warnings, text)
+			}
+		}
+		if len(errs) > 0 {
+			resp.Allowed = false
+			resp.Status = &admissionStatus{Code: http.StatusForbidden, Message: strings.Join(errs, "; ")}
+		}
+	})
+}